@@ -13,6 +13,9 @@ import (
 	"github.com/hikjik/go-metrics/internal/greeting"
 	"github.com/hikjik/go-metrics/internal/server/grpc"
 	"github.com/hikjik/go-metrics/internal/server/http"
+	"github.com/hikjik/go-metrics/internal/server/scrape"
+	"github.com/hikjik/go-metrics/internal/server/statsd"
+	"github.com/hikjik/go-metrics/internal/service"
 )
 
 var (
@@ -32,13 +35,18 @@ func main() {
 
 	cfg := config.GetServerConfig()
 
+	svc, err := service.New(ctx, cfg)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to create service")
+	}
+
 	var wg sync.WaitGroup
 
 	log.Info().Msgf("Start http server: %s", cfg.Address)
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		http.NewServer(cfg).Run(ctx)
+		http.NewServer(cfg, svc).Run(ctx)
 	}()
 
 	if cfg.GRPCAddress != "" {
@@ -46,7 +54,25 @@ func main() {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			grpc.NewServer(cfg).Run(ctx)
+			grpc.NewServer(cfg, svc).Run(ctx)
+		}()
+	}
+
+	if cfg.StatsDAddress != "" {
+		log.Info().Msgf("Start statsd server: %s", cfg.StatsDAddress)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			statsd.NewServer(cfg, svc).Run(ctx)
+		}()
+	}
+
+	if len(cfg.ScrapeTargets) > 0 {
+		log.Info().Msgf("Start scraping targets: %v", cfg.ScrapeTargets)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			scrape.NewScraper(cfg, svc).Run(ctx)
 		}()
 	}
 
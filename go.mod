@@ -7,6 +7,7 @@ require (
 	github.com/caarlos0/env/v6 v6.9.1
 	github.com/go-chi/chi/v5 v5.0.7
 	github.com/go-critic/go-critic v0.6.3
+	github.com/golang/snappy v0.0.4
 	github.com/gostaticanalysis/nilerr v0.1.1
 	github.com/gostaticanalysis/unused v0.0.5
 	github.com/jackc/pgx/v4 v4.16.0
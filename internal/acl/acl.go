@@ -0,0 +1,129 @@
+// Package acl предназначен для ограничения доступа агентов к серверу по IP-адресу
+package acl
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// ACL проверяет, разрешен ли доступ к серверу с IP-адреса агента.
+//
+// Адрес, входящий в одну из запрещенных подсетей deny, отклоняется.
+// Если список разрешенных подсетей allow пуст, разрешены все остальные адреса,
+// иначе адрес должен входить в одну из разрешенных подсетей.
+//
+// Адрес агента берется из заголовков X-Real-IP и X-Forwarded-For
+// только для запросов, пришедших с адресов доверенных прокси-серверов proxies.
+type ACL struct {
+	allow   []*net.IPNet
+	deny    []*net.IPNet
+	proxies []*net.IPNet
+}
+
+// New создает ACL по спискам подсетей в формате CIDR
+func New(allow, deny, proxies []string) (*ACL, error) {
+	var a ACL
+	var err error
+	if a.allow, err = parseSubnets(allow); err != nil {
+		return nil, err
+	}
+	if a.deny, err = parseSubnets(deny); err != nil {
+		return nil, err
+	}
+	if a.proxies, err = parseSubnets(proxies); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// Allowed возвращает true, если доступ с адреса ip разрешен
+func (a *ACL) Allowed(ip net.IP) bool {
+	if a == nil {
+		return true
+	}
+	if ip == nil {
+		return len(a.allow) == 0 && len(a.deny) == 0
+	}
+	if contains(a.deny, ip) {
+		return false
+	}
+	return len(a.allow) == 0 || contains(a.allow, ip)
+}
+
+// ClientIP возвращает адрес агента по адресу соединения remoteAddr
+// и значениям заголовков X-Real-IP и X-Forwarded-For.
+// Заголовки учитываются, только если remoteAddr является адресом доверенного прокси-сервера.
+// Если адрес не удалось определить, возвращается nil.
+func (a *ACL) ClientIP(remoteAddr string, realIP []string, forwardedFor []string) net.IP {
+	ip := parseIP(remoteAddr)
+	if a == nil || ip == nil || !contains(a.proxies, ip) {
+		return ip
+	}
+
+	for _, value := range realIP {
+		if realAddr := parseIP(strings.TrimSpace(value)); realAddr != nil {
+			return realAddr
+		}
+	}
+
+	// адреса в X-Forwarded-For перечислены от агента к последнему прокси-серверу,
+	// поэтому адресом агента считается последний адрес, не являющийся доверенным прокси
+	var hops []string
+	for _, value := range forwardedFor {
+		hops = append(hops, strings.Split(value, ",")...)
+	}
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := parseIP(strings.TrimSpace(hops[i]))
+		if hop == nil {
+			break
+		}
+		ip = hop
+		if !contains(a.proxies, hop) {
+			break
+		}
+	}
+	return ip
+}
+
+// parseIP разбирает IP-адрес, записанный с номером порта или без него
+func parseIP(addr string) net.IP {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		addr = host
+	}
+	return net.ParseIP(addr)
+}
+
+func parseSubnets(values []string) ([]*net.IPNet, error) {
+	subnets := make([]*net.IPNet, 0, len(values))
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		if !strings.Contains(value, "/") {
+			if ip := net.ParseIP(value); ip != nil {
+				bits := 8 * len(ip.To4())
+				if bits == 0 {
+					bits = 8 * net.IPv6len
+				}
+				value = fmt.Sprintf("%s/%d", value, bits)
+			}
+		}
+		_, subnet, err := net.ParseCIDR(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse subnet %s: %w", value, err)
+		}
+		subnets = append(subnets, subnet)
+	}
+	return subnets, nil
+}
+
+func contains(subnets []*net.IPNet, ip net.IP) bool {
+	for _, subnet := range subnets {
+		if subnet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,98 @@
+package acl
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAllowed(t *testing.T) {
+	tests := []struct {
+		name  string
+		ip    string
+		allow []string
+		deny  []string
+		want  bool
+	}{
+		{name: "No rules", ip: "10.0.0.1", want: true},
+		{name: "No rules unknown address", ip: "", want: true},
+		{name: "Allowed subnet", ip: "10.0.0.1", allow: []string{"192.168.0.0/24", "10.0.0.0/8"}, want: true},
+		{name: "Not allowed subnet", ip: "172.16.0.1", allow: []string{"192.168.0.0/24", "10.0.0.0/8"}, want: false},
+		{name: "Denied subnet", ip: "10.1.0.1", allow: []string{"10.0.0.0/8"}, deny: []string{"10.1.0.0/16"}, want: false},
+		{name: "Denied address", ip: "10.0.0.1", deny: []string{"10.0.0.1"}, want: false},
+		{name: "Not denied address", ip: "10.0.0.2", deny: []string{"10.0.0.1"}, want: true},
+		{name: "Unknown address with rules", ip: "", deny: []string{"10.0.0.1"}, want: false},
+		{name: "IPv6 subnet", ip: "2001:db8::1", allow: []string{"2001:db8::/32"}, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := New(tt.allow, tt.deny, nil)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, a.Allowed(net.ParseIP(tt.ip)))
+		})
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	tests := []struct {
+		name         string
+		remoteAddr   string
+		want         string
+		realIP       []string
+		forwardedFor []string
+	}{
+		{
+			name:       "Remote address with port",
+			remoteAddr: "10.0.0.1:5000",
+			want:       "10.0.0.1",
+		},
+		{
+			name:       "Headers from untrusted address",
+			remoteAddr: "10.0.0.1:5000",
+			realIP:     []string{"192.168.0.5"},
+			want:       "10.0.0.1",
+		},
+		{
+			name:       "X-Real-IP from trusted proxy",
+			remoteAddr: "172.16.0.1:5000",
+			realIP:     []string{"192.168.0.5"},
+			want:       "192.168.0.5",
+		},
+		{
+			name:         "X-Forwarded-For from trusted proxy",
+			remoteAddr:   "172.16.0.1:5000",
+			forwardedFor: []string{"1.2.3.4, 192.168.0.5", "172.16.0.2"},
+			want:         "192.168.0.5",
+		},
+		{
+			name:         "X-Forwarded-For only from proxies",
+			remoteAddr:   "172.16.0.1:5000",
+			forwardedFor: []string{"172.16.0.3, 172.16.0.2"},
+			want:         "172.16.0.3",
+		},
+		{
+			name:       "Trusted proxy without headers",
+			remoteAddr: "172.16.0.1:5000",
+			want:       "172.16.0.1",
+		},
+		{
+			name:       "Invalid remote address",
+			remoteAddr: "invalid",
+		},
+	}
+
+	a, err := New(nil, nil, []string{"172.16.0.0/12"})
+	require.NoError(t, err)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, net.ParseIP(tt.want), a.ClientIP(tt.remoteAddr, tt.realIP, tt.forwardedFor))
+		})
+	}
+}
+
+func TestNewInvalidSubnet(t *testing.T) {
+	_, err := New([]string{"invalid"}, nil, nil)
+	assert.Error(t, err)
+}
@@ -5,58 +5,282 @@ package agent
 
 import (
 	"context"
+	"errors"
+	"os"
 	"time"
 
 	"github.com/rs/zerolog/log"
 
+	"github.com/hikjik/go-metrics/internal/agent/exporter"
+	"github.com/hikjik/go-metrics/internal/agent/ingest"
+	"github.com/hikjik/go-metrics/internal/agent/queue"
 	"github.com/hikjik/go-metrics/internal/agent/sender"
 	"github.com/hikjik/go-metrics/internal/agent/sender/grpc"
 	"github.com/hikjik/go-metrics/internal/agent/sender/http"
 	"github.com/hikjik/go-metrics/internal/config"
 	"github.com/hikjik/go-metrics/internal/metrics"
+	"github.com/hikjik/go-metrics/internal/metrics/sources"
 	"github.com/hikjik/go-metrics/internal/scheduler"
+	"github.com/hikjik/go-metrics/internal/tlsutil"
+)
+
+// Интервалы между повторными попытками отправки метрик
+const (
+	minRetryDelay = time.Second
+	maxRetryDelay = time.Minute
 )
 
 type Agent struct {
 	collector      *metrics.Collector
+	tracker        *metrics.DeltaTracker
+	cumulative     *metrics.DeltaTracker
 	signer         metrics.Signer
 	sender         sender.MetricSender
-	pollInterval   time.Duration
+	queue          *queue.Queue
+	exporter       *exporter.Exporter
+	ingest         *ingest.Aggregator
+	listener       *ingest.Listener
+	labels         map[string]string
+	pending        chan struct{}
+	accepted       int
+	rejected       int
 	reportInterval time.Duration
 }
 
 func New(cfg config.AgentConfig) *Agent {
+	enabled, err := sources.New(cfg)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to setup metric sources")
+	}
+	collector, err := metrics.NewCollector(enabled...)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to register metric sources")
+	}
+
 	agent := &Agent{
-		collector:      metrics.NewCollector(),
+		collector:      collector,
+		tracker:        metrics.NewDeltaTracker(),
+		cumulative:     metrics.NewCumulativeTracker(),
 		signer:         metrics.NewHMACSigner(cfg.SignatureKey),
-		sender:         http.New(cfg.Address, cfg.PublicKeyPath),
-		pollInterval:   cfg.PollInterval,
+		queue:          queue.New(cfg.QueueFile, cfg.QueueSize, cfg.QueueMaxAge),
+		labels:         newLabels(cfg),
+		pending:        make(chan struct{}, 1),
 		reportInterval: cfg.ReportInterval,
 	}
-	if cfg.GRPCAddress != "" {
-		agent.sender = grpc.New(cfg.GRPCAddress)
-	} else {
-		agent.sender = http.New(cfg.Address, cfg.PublicKeyPath)
+	if !cfg.DisablePush {
+		agent.sender = newSender(cfg)
+		if cfg.QueueFile == "" {
+			log.Warn().Msg("QUEUE_FILE is not set, undelivered counter increments will be lost on restart")
+		}
+	}
+	if cfg.MetricsAddress != "" {
+		agent.exporter = exporter.New(cfg.MetricsAddress, agent)
+	}
+	if cfg.IngestSocket != "" || cfg.IngestAddress != "" {
+		if cfg.DisablePush {
+			log.Fatal().Msg("Ingestion of application metrics requires sending metrics to server")
+		}
+		agent.ingest = ingest.NewAggregator()
+		agent.listener, err = ingest.NewListener(cfg.IngestSocket, cfg.IngestAddress, agent.ingest)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to setup ingestion of application metrics")
+		}
 	}
 	return agent
 }
 
+// newSender создает транспорт для отправки метрик на сервер.
+// Шифрование тела запроса ключом CRYPTO_KEY поддерживается только HTTP-транспортом,
+// поэтому вместе с gRPC агент с этим ключом не запускается, чтобы не отправлять
+// метрики в открытом виде.
+func newSender(cfg config.AgentConfig) sender.MetricSender {
+	if cfg.GRPCAddress != "" {
+		tlsConfig, err := tlsutil.ClientConfig(cfg.GRPCTLS)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to setup grpc tls")
+		}
+		if cfg.PublicKeyPath != "" {
+			log.Fatal().Msg("CRYPTO_KEY is not supported by grpc transport, use GRPC_TLS options instead")
+		}
+		return grpc.New(cfg.GRPCAddress, tlsConfig)
+	}
+
+	tlsConfig, err := tlsutil.ClientConfig(cfg.HTTPTLS)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to setup http tls")
+	}
+	return http.New(cfg.Address, cfg.Scheme, tlsConfig, cfg.PublicKeyPath, cfg.CryptoFormat)
+}
+
+// Run запускает сбор метрик, их отправку на сервер, если она не отключена,
+// и HTTP-сервер для сбора метрик по модели pull, если задан его адрес
 func (a *Agent) Run(ctx context.Context) {
 	s := scheduler.New()
 
-	s.Add(ctx, a.collector.UpdateRuntimeMetrics, a.pollInterval)
-	s.Add(ctx, a.collector.UpdateUtilizationMetrics, a.pollInterval)
-	s.Add(ctx, a.sendMetrics(ctx), a.reportInterval)
+	for _, source := range a.collector.Sources() {
+		source := source
+		s.Add(ctx, func() { a.collector.Update(ctx, source) }, source.Interval())
+	}
+
+	if a.exporter != nil {
+		log.Info().Msgf("Start metrics http server: %s", a.exporter.Address)
+		go a.exporter.Run(ctx)
+	}
+
+	if a.listener != nil {
+		log.Info().Msgf("Start ingestion of application metrics: %s %s", a.listener.SocketPath, a.listener.UDPAddress)
+		go a.listener.Run(ctx)
+	}
+
+	if a.sender != nil {
+		s.Add(ctx, a.sendMetrics, a.reportInterval)
+		go a.deliver(ctx)
+		a.notify()
+	}
+}
+
+// Snapshot возвращает текущие значения метрик с метками агента.
+// В отличие от отправляемых на сервер наборов, счетчики содержат накопленные значения.
+func (a *Agent) Snapshot() []*metrics.Metric {
+	return a.snapshot(nil)
+}
+
+// snapshot возвращает текущие значения метрик источников, для которых filter возвращает true,
+// с метками агента
+func (a *Agent) snapshot(filter func(metrics.Source) bool) []*metrics.Metric {
+	collection := a.collector.ListSourceMetrics(filter)
+	for _, metric := range collection {
+		metric.Labels = metrics.MergeLabels(a.labels, metric.Labels)
+	}
+	return collection
+}
+
+// sendMetrics ставит текущие значения метрик в очередь на отправку.
+// Накопленные значения счетчиков заменяются приращениями с момента предыдущего вызова:
+// DeltaTracker фиксирует приращения при постановке в очередь, а не при подтверждении
+// сервером, поэтому их доставку гарантирует очередь. Приращения переживают перезапуск
+// агента, только если очередь сохраняется в файл QUEUE_FILE. Приращения метрик,
+// отклоненных сервером, не отправляются повторно.
+// Накопленные значения источников, не реализующих metrics.AgentSource, включают события
+// до запуска агента, поэтому их первые значения после запуска только запоминаются.
+// К набору добавляются метрики приложений, полученные с момента предыдущего вызова.
+func (a *Agent) sendMetrics() {
+	collection := a.tracker.Delta(a.snapshot(metrics.StartsWithAgent))
+	collection = append(collection, a.cumulative.Delta(a.snapshot(func(source metrics.Source) bool {
+		return !metrics.StartsWithAgent(source)
+	}))...)
+	if a.ingest != nil {
+		for _, metric := range a.ingest.Flush() {
+			metric.Labels = metrics.MergeLabels(a.labels, metric.Labels)
+			collection = append(collection, metric)
+		}
+	}
+	a.queue.Push(collection)
+	a.notify()
+}
+
+// notify сообщает о появлении в очереди новых метрик
+func (a *Agent) notify() {
+	select {
+	case a.pending <- struct{}{}:
+	default:
+	}
 }
 
-func (a *Agent) sendMetrics(ctx context.Context) func() {
-	return func() {
-		collection := a.collector.ListMetrics()
-		for _, metric := range collection {
-			if err := a.signer.Sign(metric); err != nil {
-				log.Warn().Err(err).Msg("Failed to set hash")
+// deliver отправляет наборы метрик из очереди в порядке их сбора.
+// При ошибке отправка повторяется с экспоненциально растущим интервалом,
+// наборы, отклоненные сервером целиком, удаляются из очереди.
+func (a *Agent) deliver(ctx context.Context) {
+	delay := minRetryDelay
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-a.pending:
+		}
+
+		for batch := a.queue.Peek(); batch != nil; batch = a.queue.Peek() {
+			result, err := a.send(ctx, batch)
+			if err == nil {
+				a.queue.Pop(batch)
+				a.report(result)
+				delay = minRetryDelay
+				continue
+			}
+			if errors.Is(err, sender.ErrPermanent) {
+				// повторная отправка набора будет отклонена, и он заблокирует очередь
+				a.queue.Pop(batch)
+				a.rejected += len(batch.Metrics)
+				log.Error().Err(err).Msgf("Dropped %d metrics rejected by server", len(batch.Metrics))
+				delay = minRetryDelay
+				continue
+			}
+
+			a.queue.Release()
+			log.Warn().Err(err).Msgf("Failed to send metrics, retry in %s", delay)
+
+			timer := time.NewTimer(delay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+
+			delay *= 2
+			if delay > maxRetryDelay {
+				delay = maxRetryDelay
 			}
 		}
-		a.sender.Send(ctx, collection)
 	}
 }
+
+// send подписывает и отправляет набор метрик. Подпись вычисляется при каждой отправке,
+// так как значения счетчиков могут измениться при объединении наборов в очереди.
+// Подписываются копии метрик: набор в очереди может одновременно сохраняться в файл.
+func (a *Agent) send(ctx context.Context, batch *queue.Batch) (*sender.Result, error) {
+	collection := make([]*metrics.Metric, 0, len(batch.Metrics))
+	for _, metric := range batch.Metrics {
+		metric = metric.Copy()
+		if err := a.signer.Sign(metric); err != nil {
+			log.Warn().Err(err).Msg("Failed to set hash")
+		}
+		collection = append(collection, metric)
+	}
+	return a.sender.Send(ctx, collection)
+}
+
+// report сохраняет статистику отправки и выводит в лог причины отказа в приеме метрик
+func (a *Agent) report(result *sender.Result) {
+	a.accepted += result.Accepted
+	a.rejected += len(result.Rejected)
+	for _, status := range result.Rejected {
+		log.Warn().Msgf("Metric %s of type %s rejected: %s", status.ID, status.MType, status.Error)
+	}
+	if len(result.Rejected) > 0 {
+		log.Info().Msgf("Metrics accepted: %d, rejected: %d", a.accepted, a.rejected)
+	}
+}
+
+// newLabels возвращает метки, добавляемые ко всем отправляемым метрикам:
+// имя хоста host, идентификатор агента agent_id и метки из настроек агента
+func newLabels(cfg config.AgentConfig) map[string]string {
+	host, err := os.Hostname()
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to get host name")
+	}
+
+	agentID := cfg.AgentID
+	if agentID == "" {
+		agentID = host
+	}
+
+	labels := metrics.MergeLabels(map[string]string{"agent_id": agentID}, cfg.Labels)
+	if host != "" {
+		labels = metrics.MergeLabels(map[string]string{"host": host}, labels)
+	}
+	if !metrics.ValidateLabels(labels) {
+		log.Fatal().Msgf("Invalid metric labels: %v", labels)
+	}
+	return labels
+}
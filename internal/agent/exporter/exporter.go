@@ -0,0 +1,84 @@
+// Package exporter содержит реализацию HTTP-сервера агента,
+// публикующего текущие значения метрик для сбора по модели pull.
+package exporter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/rs/zerolog/log"
+
+	"github.com/hikjik/go-metrics/internal/metrics"
+	"github.com/hikjik/go-metrics/internal/prometheus"
+)
+
+// Snapshotter возвращает текущие значения метрик
+type Snapshotter interface {
+	Snapshot() []*metrics.Metric
+}
+
+type Exporter struct {
+	Source  Snapshotter
+	Address string
+}
+
+// New создает Exporter, публикующий метрики source по адресу address
+func New(address string, source Snapshotter) *Exporter {
+	return &Exporter{
+		Source:  source,
+		Address: address,
+	}
+}
+
+// Route регистрирует обработчики и возвращает роутер
+func (e *Exporter) Route() *chi.Mux {
+	router := chi.NewRouter()
+	router.Use(middleware.Compress(5))
+	router.Get("/metrics", e.GetPrometheusMetrics())
+	router.Get("/metrics.json", e.GetJSONMetrics())
+	return router
+}
+
+// Run обслуживает запросы до завершения контекста ctx
+func (e *Exporter) Run(ctx context.Context) {
+	srv := &http.Server{
+		Addr:    e.Address,
+		Handler: e.Route(),
+	}
+
+	go func() {
+		<-ctx.Done()
+		if err := srv.Shutdown(context.Background()); err != nil {
+			log.Error().Err(err).Msg("Failed to shutdown metrics HTTP server")
+		}
+	}()
+
+	if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		log.Error().Err(err).Msg("Error on metrics http server ListenAndServe")
+	}
+}
+
+// GetPrometheusMetrics обработчик, возвращающий текущие значения метрик
+// в текстовом формате Prometheus. Счетчики публикуются с накопленными значениями.
+func (e *Exporter) GetPrometheusMetrics() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", prometheus.ContentType)
+		if err := prometheus.Encode(w, e.Source.Snapshot()); err != nil {
+			log.Warn().Err(err).Msg("Failed to encode metrics")
+		}
+	}
+}
+
+// GetJSONMetrics обработчик, возвращающий текущие значения метрик в формате JSON
+func (e *Exporter) GetJSONMetrics() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(e.Source.Snapshot()); err != nil {
+			log.Warn().Err(err).Msg("Failed to encode metrics")
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package exporter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hikjik/go-metrics/internal/metrics"
+	"github.com/hikjik/go-metrics/internal/prometheus"
+)
+
+type staticSource []*metrics.Metric
+
+func (s staticSource) Snapshot() []*metrics.Metric {
+	return s
+}
+
+func TestExporter(t *testing.T) {
+	gauge := metrics.NewGauge("Alloc", 1.5)
+	gauge.Labels = map[string]string{"host": "a"}
+	source := staticSource{metrics.NewCounter("PollCount", 3), gauge}
+	router := New("", source).Route()
+
+	tests := []struct {
+		name        string
+		target      string
+		contentType string
+		want        string
+	}{
+		{
+			name:        "Prometheus",
+			target:      "/metrics",
+			contentType: prometheus.ContentType,
+			want: "# HELP Alloc Metric Alloc of type gauge.\n" +
+				"# TYPE Alloc gauge\n" +
+				"Alloc{host=\"a\"} 1.5\n" +
+				"# HELP PollCount_total Metric PollCount of type counter.\n" +
+				"# TYPE PollCount_total counter\n" +
+				"PollCount_total 3\n",
+		},
+		{
+			name:        "JSON",
+			target:      "/metrics.json",
+			contentType: "application/json",
+			want: `[{"id":"PollCount","type":"counter","delta":3},` +
+				`{"id":"Alloc","type":"gauge","value":1.5,"labels":{"host":"a"}}]` + "\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.target, nil))
+			require.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, tt.contentType, w.Header().Get("Content-Type"))
+			assert.Equal(t, tt.want, w.Body.String())
+		})
+	}
+}
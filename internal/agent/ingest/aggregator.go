@@ -0,0 +1,161 @@
+// Package ingest содержит реализацию локального приема метрик приложений агентом
+// через Unix-сокет и UDP-порт на localhost.
+package ingest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+
+	"github.com/hikjik/go-metrics/internal/metrics"
+	"github.com/hikjik/go-metrics/internal/statsd"
+)
+
+// ErrUnsupportedMetric возвращается при получении метрики, тип или значение которой не поддерживается
+var ErrUnsupportedMetric = errors.New("unsupported metric")
+
+// Aggregator накапливает метрики приложений между отправками на сервер:
+// приращения счетчиков суммируются, для метрик типа GaugeType сохраняется последнее значение.
+type Aggregator struct {
+	pending map[string]*metrics.Metric
+	// gauges содержит последние значения метрик типа GaugeType,
+	// относительно которых применяются изменения StatsD вида gauge:+5|g
+	gauges map[string]float64
+	mu     sync.Mutex
+}
+
+// NewAggregator создает экземпляр Aggregator
+func NewAggregator() *Aggregator {
+	return &Aggregator{
+		pending: make(map[string]*metrics.Metric),
+		gauges:  make(map[string]float64),
+	}
+}
+
+// HandleLine разбирает и добавляет метрики из строки. Строки, начинающиеся с '{' или '[',
+// разбираются как метрика или список метрик в формате JSON, остальные - как строка StatsD.
+// Счетчики в формате JSON содержат приращения, а не накопленные значения.
+func (a *Aggregator) HandleLine(line string) error {
+	if strings.HasPrefix(line, "{") || strings.HasPrefix(line, "[") {
+		var collection []*metrics.Metric
+		if strings.HasPrefix(line, "{") {
+			collection = []*metrics.Metric{{}}
+			if err := json.Unmarshal([]byte(line), collection[0]); err != nil {
+				return err
+			}
+		} else if err := json.Unmarshal([]byte(line), &collection); err != nil {
+			return err
+		}
+		for _, m := range collection {
+			if err := a.Add(m); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	sample, err := statsd.Parse(line)
+	if err != nil {
+		return err
+	}
+	return a.AddStatsD(sample)
+}
+
+// Add добавляет метрику типа CounterType с приращением или метрику типа GaugeType
+func (a *Aggregator) Add(m *metrics.Metric) error {
+	if m == nil || m.ID == "" {
+		return fmt.Errorf("%w: empty metric name", ErrUnsupportedMetric)
+	}
+	if !metrics.ValidateLabels(m.Labels) {
+		return fmt.Errorf("%w: invalid labels of %s", ErrUnsupportedMetric, m.ID)
+	}
+
+	switch {
+	case m.MType == metrics.CounterType && m.Delta != nil:
+		return a.addCounter(m.ID, m.Labels, *m.Delta)
+	case m.MType == metrics.GaugeType && m.Value != nil:
+		return a.setGauge(m.ID, m.Labels, *m.Value, false)
+	default:
+		return fmt.Errorf("%w: %s of type %s", ErrUnsupportedMetric, m.ID, m.MType)
+	}
+}
+
+// AddStatsD добавляет счетчик или gauge в формате StatsD.
+// Приращения счетчиков пересчитываются с учетом частоты выборки.
+func (a *Aggregator) AddStatsD(sample statsd.Sample) error {
+	if !metrics.ValidateLabels(sample.Labels) {
+		return fmt.Errorf("%w: invalid labels of %s", ErrUnsupportedMetric, sample.Name)
+	}
+
+	switch sample.Type {
+	case statsd.CounterType:
+		delta := math.Round(sample.Value / sample.Rate)
+		// float64(math.MaxInt64) равно 2^63 и уже не помещается в int64
+		if math.IsNaN(delta) || delta < math.MinInt64 || delta >= math.MaxInt64 {
+			return fmt.Errorf("%w: counter %s value %v is out of range", ErrUnsupportedMetric, sample.Name, sample.Value)
+		}
+		return a.addCounter(sample.Name, sample.Labels, int64(delta))
+	case statsd.GaugeType:
+		return a.setGauge(sample.Name, sample.Labels, sample.Value, sample.Relative)
+	default:
+		return fmt.Errorf("%w: %s of statsd type %s", ErrUnsupportedMetric, sample.Name, sample.Type)
+	}
+}
+
+// Flush возвращает накопленные с предыдущего вызова метрики и очищает их
+func (a *Aggregator) Flush() []*metrics.Metric {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	collection := make([]*metrics.Metric, 0, len(a.pending))
+	for _, m := range a.pending {
+		collection = append(collection, m)
+	}
+	a.pending = make(map[string]*metrics.Metric)
+	return collection
+}
+
+// addCounter прибавляет приращение delta к накопленному приращению счетчика.
+// Приращение, при котором сумма выходит за пределы int64, отклоняется.
+func (a *Aggregator) addCounter(id string, labels map[string]string, delta int64) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	m := metrics.NewCounter(id, delta)
+	m.Labels = labels
+	key := metrics.CounterType + ":" + m.SeriesKey()
+	if current, ok := a.pending[key]; ok {
+		sum := *current.Delta + delta
+		if delta > 0 && sum < *current.Delta || delta < 0 && sum > *current.Delta {
+			return fmt.Errorf("%w: counter %s overflow", ErrUnsupportedMetric, id)
+		}
+		*current.Delta = sum
+		return nil
+	}
+	a.pending[key] = m
+	return nil
+}
+
+// setGauge сохраняет значение метрики типа GaugeType или, если задан relative,
+// изменяет ее текущее значение на value. Значения NaN и ±Inf отклоняются:
+// их нельзя передать на сервер в формате JSON.
+func (a *Aggregator) setGauge(id string, labels map[string]string, value float64, relative bool) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	m := metrics.NewGauge(id, value)
+	m.Labels = labels
+	key := metrics.GaugeType + ":" + m.SeriesKey()
+	if relative {
+		*m.Value += a.gauges[key]
+	}
+	if math.IsNaN(*m.Value) || math.IsInf(*m.Value, 0) {
+		return fmt.Errorf("%w: gauge %s value is not finite", ErrUnsupportedMetric, id)
+	}
+	a.gauges[key] = *m.Value
+	a.pending[key] = m
+	return nil
+}
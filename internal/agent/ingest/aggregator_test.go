@@ -0,0 +1,78 @@
+package ingest
+
+import (
+	"math"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hikjik/go-metrics/internal/metrics"
+)
+
+func TestAggregator(t *testing.T) {
+	aggregator := NewAggregator()
+	for _, line := range []string{
+		"requests:1|c",
+		"requests:2|c|@0.5",
+		`{"id":"requests","type":"counter","delta":3}`,
+		`{"id":"requests","type":"counter","delta":1,"labels":{"path":"/api"}}`,
+		"memory:10|g",
+		"memory:-3|g",
+		`[{"id":"queue","type":"gauge","value":5},{"id":"queue","type":"gauge","value":2}]`,
+	} {
+		require.NoError(t, aggregator.HandleLine(line), line)
+	}
+
+	counter := metrics.NewCounter("requests", 1)
+	counter.Labels = map[string]string{"path": "/api"}
+	assert.Equal(t, []*metrics.Metric{
+		metrics.NewGauge("memory", 7),
+		metrics.NewGauge("queue", 2),
+		metrics.NewCounter("requests", 8),
+		counter,
+	}, sorted(aggregator.Flush()))
+
+	assert.Empty(t, aggregator.Flush())
+
+	// изменение gauge применяется к последнему значению, полученному до отправки
+	require.NoError(t, aggregator.HandleLine("memory:+1|g"))
+	assert.Equal(t, []*metrics.Metric{metrics.NewGauge("memory", 8)}, aggregator.Flush())
+}
+
+func TestAggregatorInvalid(t *testing.T) {
+	aggregator := NewAggregator()
+	for _, line := range []string{
+		"requests",
+		"latency:250|ms",
+		`{"id":"requests","type":"counter"}`,
+		`{"id":"","type":"gauge","value":1}`,
+		`{"id":"requests","type":"histogram"}`,
+		`{"id":"queue","type":"gauge","value":1,"labels":{"1st":"a"}}`,
+		`{"id":`,
+		"queue:NaN|g",
+		"queue:+Inf|g",
+		"requests:1e300|c",
+		"requests:1e18|c|@0.01",
+	} {
+		assert.Error(t, aggregator.HandleLine(line), line)
+	}
+	assert.Empty(t, aggregator.Flush())
+
+	require.NoError(t, aggregator.HandleLine("queue:1e308|g"))
+	assert.Error(t, aggregator.HandleLine("queue:+1e308|g"))
+	assert.Error(t, aggregator.Add(metrics.NewGauge("queue", math.Inf(1))))
+	assert.Equal(t, []*metrics.Metric{metrics.NewGauge("queue", 1e308)}, aggregator.Flush())
+
+	require.NoError(t, aggregator.Add(metrics.NewCounter("requests", math.MaxInt64)))
+	assert.Error(t, aggregator.Add(metrics.NewCounter("requests", 1)))
+	assert.Equal(t, []*metrics.Metric{metrics.NewCounter("requests", math.MaxInt64)}, aggregator.Flush())
+}
+
+func sorted(collection []*metrics.Metric) []*metrics.Metric {
+	sort.Slice(collection, func(i, j int) bool {
+		return collection[i].SeriesKey() < collection[j].SeriesKey()
+	})
+	return collection
+}
@@ -0,0 +1,166 @@
+package ingest
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+)
+
+// maxPacketSize максимальный размер UDP-пакета
+const maxPacketSize = 65535
+
+// Listener принимает метрики приложений через Unix-сокет и UDP-порт на localhost
+// и передает их Aggregator. Каждая строка содержит метрику в формате JSON или StatsD.
+type Listener struct {
+	Aggregator *Aggregator
+	SocketPath string
+	UDPAddress string
+}
+
+// NewListener создает Listener, принимающий метрики через Unix-сокет socketPath
+// и UDP-адрес udpAddress. Пустые значения отключают соответствующий способ приема.
+// Для приема по UDP допускаются только адреса интерфейса loopback.
+func NewListener(socketPath, udpAddress string, aggregator *Aggregator) (*Listener, error) {
+	if udpAddress != "" {
+		host, _, err := net.SplitHostPort(udpAddress)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ingest udp address %s: %w", udpAddress, err)
+		}
+		if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+			return nil, fmt.Errorf("ingest udp address %s is not a loopback address", udpAddress)
+		}
+	}
+	return &Listener{
+		Aggregator: aggregator,
+		SocketPath: socketPath,
+		UDPAddress: udpAddress,
+	}, nil
+}
+
+// Run принимает метрики до завершения контекста ctx
+func (l *Listener) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+
+	if l.SocketPath != "" {
+		// сокет мог остаться после аварийного завершения агента
+		if err := os.Remove(l.SocketPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			log.Warn().Err(err).Msg("Failed to remove ingest socket")
+		}
+		listener, err := net.Listen("unix", l.SocketPath)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to start ingest socket listener")
+		}
+		closeOnDone(ctx, listener, "Failed to close ingest socket listener")
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			l.serveStream(ctx, listener)
+		}()
+	}
+
+	if l.UDPAddress != "" {
+		conn, err := net.ListenPacket("udp", l.UDPAddress)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to start ingest udp listener")
+		}
+		closeOnDone(ctx, conn, "Failed to close ingest udp listener")
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			l.serveUDP(conn)
+		}()
+	}
+
+	wg.Wait()
+}
+
+func (l *Listener) serveUDP(conn net.PacketConn) {
+	buf := make([]byte, maxPacketSize)
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if errors.Is(err, net.ErrClosed) {
+			return
+		}
+		if err != nil {
+			log.Warn().Err(err).Msg("Failed to read ingest packet")
+			continue
+		}
+
+		for _, line := range bytes.Split(buf[:n], []byte("\n")) {
+			l.handleLine(string(bytes.TrimSpace(line)))
+		}
+	}
+}
+
+func (l *Listener) serveStream(ctx context.Context, listener net.Listener) {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for {
+		conn, err := listener.Accept()
+		if errors.Is(err, net.ErrClosed) {
+			return
+		}
+		if err != nil {
+			log.Warn().Err(err).Msg("Failed to accept ingest connection")
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			l.serveConn(ctx, conn)
+		}()
+	}
+}
+
+func (l *Listener) serveConn(ctx context.Context, conn net.Conn) {
+	defer func() {
+		if err := conn.Close(); err != nil && !errors.Is(err, net.ErrClosed) {
+			log.Warn().Err(err).Msg("Failed to close ingest connection")
+		}
+	}()
+
+	go func() {
+		// прерываем чтение при завершении работы агента
+		<-ctx.Done()
+		_ = conn.Close()
+	}()
+
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), maxPacketSize)
+	for scanner.Scan() {
+		l.handleLine(string(bytes.TrimSpace(scanner.Bytes())))
+	}
+	if err := scanner.Err(); err != nil && !errors.Is(err, net.ErrClosed) {
+		log.Warn().Err(err).Msg("Failed to read ingest connection")
+	}
+}
+
+func (l *Listener) handleLine(line string) {
+	if line == "" {
+		return
+	}
+	if err := l.Aggregator.HandleLine(line); err != nil {
+		log.Warn().Err(err).Msg("Failed to handle ingested metric")
+	}
+}
+
+// closeOnDone закрывает c после завершения контекста ctx
+func closeOnDone(ctx context.Context, c interface{ Close() error }, message string) {
+	go func() {
+		<-ctx.Done()
+		if err := c.Close(); err != nil {
+			log.Error().Err(err).Msg(message)
+		}
+	}()
+}
@@ -0,0 +1,91 @@
+package ingest
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hikjik/go-metrics/internal/metrics"
+)
+
+func TestNewListener(t *testing.T) {
+	for _, address := range []string{"127.0.0.1:8125", "localhost:8125", "[::1]:8125"} {
+		_, err := NewListener("", address, NewAggregator())
+		assert.NoError(t, err, address)
+	}
+	for _, address := range []string{"0.0.0.0:8125", "192.168.1.1:8125", ":8125", "localhost"} {
+		_, err := NewListener("", address, NewAggregator())
+		assert.Error(t, err, address)
+	}
+}
+
+func TestListener(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "ingest.sock")
+	listener, err := NewListener(socket, "127.0.0.1:0", NewAggregator())
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		listener.Run(ctx)
+		close(done)
+	}()
+
+	var conn net.Conn
+	require.Eventually(t, func() bool {
+		conn, err = net.Dial("unix", socket)
+		return err == nil
+	}, time.Second, time.Millisecond*10)
+
+	_, err = conn.Write([]byte("requests:2|c\n" + `{"id":"queue","type":"gauge","value":3}` + "\n"))
+	require.NoError(t, err)
+	require.NoError(t, conn.Close())
+
+	require.Eventually(t, func() bool {
+		listener.Aggregator.mu.Lock()
+		defer listener.Aggregator.mu.Unlock()
+		return len(listener.Aggregator.pending) == 2
+	}, time.Second, time.Millisecond*10)
+
+	cancel()
+	<-done
+
+	assert.Equal(t, []*metrics.Metric{
+		metrics.NewGauge("queue", 3),
+		metrics.NewCounter("requests", 2),
+	}, sorted(listener.Aggregator.Flush()))
+}
+
+func TestServeUDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	listener, err := NewListener("", conn.LocalAddr().String(), NewAggregator())
+	require.NoError(t, err)
+	done := make(chan struct{})
+	go func() {
+		listener.serveUDP(conn)
+		close(done)
+	}()
+
+	client, err := net.Dial("udp", conn.LocalAddr().String())
+	require.NoError(t, err)
+	_, err = client.Write([]byte("requests:1|c\nrequests:4|c\n"))
+	require.NoError(t, err)
+	require.NoError(t, client.Close())
+
+	require.Eventually(t, func() bool {
+		listener.Aggregator.mu.Lock()
+		defer listener.Aggregator.mu.Unlock()
+		return len(listener.Aggregator.pending) == 1
+	}, time.Second, time.Millisecond*10)
+
+	require.NoError(t, conn.Close())
+	<-done
+	assert.Equal(t, []*metrics.Metric{metrics.NewCounter("requests", 5)}, listener.Aggregator.Flush())
+}
@@ -0,0 +1,243 @@
+// Package queue содержит реализацию очереди неотправленных агентом метрик.
+// Очередь хранит наборы метрик в порядке их сбора и при необходимости сохраняет их в файл,
+// чтобы метрики не терялись при недоступности сервера и перезапуске агента.
+package queue
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/hikjik/go-metrics/internal/metrics"
+)
+
+// Batch содержит набор метрик, собранных агентом в момент времени Created
+type Batch struct {
+	Created time.Time         `json:"created"`
+	Metrics []*metrics.Metric `json:"metrics"`
+}
+
+// Queue очередь наборов метрик, ожидающих отправки на сервер.
+//
+// Размер очереди ограничен maxSize наборами: при переполнении два самых старых набора
+// объединяются в один, при этом приращения счетчиков и гистограмм складываются,
+// а для метрик типа GaugeType сохраняется последнее значение.
+// Наборы старше maxAge теряют значения метрик типа GaugeType,
+// а их приращения переносятся в следующий набор.
+type Queue struct {
+	path     string
+	batches  []*Batch
+	maxAge   time.Duration
+	maxSize  int
+	mu       sync.Mutex
+	inFlight bool
+}
+
+// New создает очередь, сохраняемую в файл path. Если файл существует,
+// очередь восстанавливается из него. Для пустого path очередь хранится только в памяти.
+func New(path string, maxSize int, maxAge time.Duration) *Queue {
+	q := &Queue{
+		path:    path,
+		maxSize: maxSize,
+		maxAge:  maxAge,
+	}
+	if err := q.load(); err != nil {
+		log.Warn().Err(err).Msg("Failed to load metrics queue")
+	}
+	return q
+}
+
+// Push добавляет набор метрик в конец очереди
+func (q *Queue) Push(collection []*metrics.Metric) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.batches = append(q.batches, &Batch{
+		Created: time.Now(),
+		Metrics: collection,
+	})
+	q.compact()
+	q.save()
+}
+
+// Peek возвращает самый старый набор метрик и помечает его как отправляемый.
+// До вызова Pop или Release этот набор не изменяется при объединении наборов.
+// Если очередь пуста, возвращается nil.
+func (q *Queue) Peek() *Batch {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.expire()
+	if len(q.batches) == 0 {
+		return nil
+	}
+	q.inFlight = true
+	return q.batches[0]
+}
+
+// Pop удаляет из очереди набор, полученный с помощью Peek, после его успешной отправки
+func (q *Queue) Pop(batch *Batch) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.inFlight = false
+	if len(q.batches) > 0 && q.batches[0] == batch {
+		q.batches[0] = nil
+		q.batches = q.batches[1:]
+		q.save()
+	}
+}
+
+// Release снимает с набора, полученного с помощью Peek, отметку об отправке
+func (q *Queue) Release() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.inFlight = false
+	q.compact()
+}
+
+// Len возвращает количество наборов метрик в очереди
+func (q *Queue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return len(q.batches)
+}
+
+// first возвращает индекс первого набора, который можно изменять
+func (q *Queue) first() int {
+	if q.inFlight {
+		return 1
+	}
+	return 0
+}
+
+// compact объединяет самые старые наборы, пока размер очереди превышает maxSize
+func (q *Queue) compact() {
+	if q.maxSize <= 0 {
+		return
+	}
+
+	first := q.first()
+	for len(q.batches) > q.maxSize && len(q.batches)-first >= 2 {
+		merged := merge(q.batches[first], q.batches[first+1])
+		q.batches = append(q.batches[:first+1], q.batches[first+2:]...)
+		q.batches[first] = merged
+	}
+}
+
+// expire удаляет из устаревших наборов значения метрик типа GaugeType,
+// перенося приращения в следующий набор
+func (q *Queue) expire() {
+	if q.maxAge <= 0 {
+		return
+	}
+
+	deadline := time.Now().Add(-q.maxAge)
+	changed := false
+	for i := q.first(); i < len(q.batches) && q.batches[i].Created.Before(deadline); {
+		deltas := dropGauges(q.batches[i])
+		changed = true
+
+		switch {
+		case i+1 < len(q.batches):
+			q.batches[i+1] = merge(deltas, q.batches[i+1])
+			q.batches = append(q.batches[:i], q.batches[i+1:]...)
+		case len(deltas.Metrics) == 0:
+			q.batches = q.batches[:i]
+		default:
+			q.batches[i] = deltas
+			i++
+		}
+	}
+
+	if changed {
+		log.Warn().Msg("Dropped expired gauge values from metrics queue")
+		q.save()
+	}
+}
+
+func (q *Queue) save() {
+	if q.path == "" {
+		return
+	}
+
+	data, err := json.Marshal(q.batches)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to encode metrics queue")
+		return
+	}
+
+	tmp := q.path + ".tmp"
+	if err = os.WriteFile(tmp, data, 0600); err != nil {
+		log.Warn().Err(err).Msg("Failed to save metrics queue")
+		return
+	}
+	if err = os.Rename(tmp, q.path); err != nil {
+		log.Warn().Err(err).Msg("Failed to save metrics queue")
+	}
+}
+
+func (q *Queue) load() error {
+	if q.path == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(q.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, &q.batches)
+}
+
+// merge объединяет набор older с более новым набором newer
+func merge(older, newer *Batch) *Batch {
+	result := &Batch{
+		Created: newer.Created,
+		Metrics: make([]*metrics.Metric, 0, len(older.Metrics)+len(newer.Metrics)),
+	}
+
+	index := make(map[string]*metrics.Metric)
+	for _, batch := range []*Batch{older, newer} {
+		for _, metric := range batch.Metrics {
+			key := metric.MType + ":" + metric.SeriesKey()
+			stored, ok := index[key]
+			if !ok {
+				index[key] = metric
+				result.Metrics = append(result.Metrics, metric)
+				continue
+			}
+
+			switch metric.MType {
+			case metrics.CounterType:
+				*stored.Delta += *metric.Delta
+			case metrics.HistogramType:
+				if err := stored.Histogram.Add(metric.Histogram); err != nil {
+					*stored.Histogram = *metric.Histogram
+				}
+			default:
+				*stored = *metric
+			}
+		}
+	}
+	return result
+}
+
+// dropGauges возвращает набор, содержащий только приращения счетчиков и гистограмм
+func dropGauges(batch *Batch) *Batch {
+	result := &Batch{Created: batch.Created}
+	for _, metric := range batch.Metrics {
+		if metric.MType != metrics.GaugeType {
+			result.Metrics = append(result.Metrics, metric)
+		}
+	}
+	return result
+}
@@ -0,0 +1,108 @@
+package queue
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hikjik/go-metrics/internal/metrics"
+)
+
+func newCollection(delta int64, value float64) []*metrics.Metric {
+	return []*metrics.Metric{
+		metrics.NewCounter("PollCount", delta),
+		metrics.NewGauge("Alloc", value),
+	}
+}
+
+func TestQueueOrder(t *testing.T) {
+	q := New("", 10, time.Hour)
+	q.Push(newCollection(1, 1.0))
+	q.Push(newCollection(2, 2.0))
+	require.Equal(t, 2, q.Len())
+
+	first := q.Peek()
+	require.NotNil(t, first)
+	assert.Equal(t, int64(1), *first.Metrics[0].Delta)
+	q.Pop(first)
+
+	second := q.Peek()
+	require.NotNil(t, second)
+	assert.Equal(t, int64(2), *second.Metrics[0].Delta)
+	q.Pop(second)
+
+	assert.Nil(t, q.Peek())
+}
+
+func TestQueueCompact(t *testing.T) {
+	q := New("", 2, time.Hour)
+	q.Push(newCollection(1, 1.0))
+	q.Push(newCollection(2, 2.0))
+	q.Push(newCollection(3, 3.0))
+	require.Equal(t, 2, q.Len())
+
+	batch := q.Peek()
+	require.NotNil(t, batch)
+	require.Len(t, batch.Metrics, 2)
+	assert.Equal(t, int64(3), *batch.Metrics[0].Delta)
+	assert.Equal(t, 2.0, *batch.Metrics[1].Value)
+}
+
+func TestQueueCompactInFlight(t *testing.T) {
+	q := New("", 1, time.Hour)
+	q.Push(newCollection(1, 1.0))
+
+	batch := q.Peek()
+	q.Push(newCollection(2, 2.0))
+	q.Push(newCollection(3, 3.0))
+	require.Equal(t, 2, q.Len())
+	assert.Equal(t, int64(1), *batch.Metrics[0].Delta)
+
+	q.Pop(batch)
+	next := q.Peek()
+	require.NotNil(t, next)
+	assert.Equal(t, int64(5), *next.Metrics[0].Delta)
+	assert.Equal(t, 3.0, *next.Metrics[1].Value)
+}
+
+func TestQueueExpire(t *testing.T) {
+	q := New("", 10, time.Minute)
+	q.Push(newCollection(1, 1.0))
+	q.Push(newCollection(2, 2.0))
+	q.batches[0].Created = time.Now().Add(-time.Hour)
+
+	batch := q.Peek()
+	require.NotNil(t, batch)
+	require.Equal(t, 1, q.Len())
+	require.Len(t, batch.Metrics, 2)
+	assert.Equal(t, int64(3), *batch.Metrics[0].Delta)
+	assert.Equal(t, 2.0, *batch.Metrics[1].Value)
+	q.Pop(batch)
+
+	q.Push(newCollection(4, 4.0))
+	q.batches[0].Created = time.Now().Add(-time.Hour)
+	batch = q.Peek()
+	require.NotNil(t, batch)
+	require.Len(t, batch.Metrics, 1)
+	assert.Equal(t, metrics.CounterType, batch.Metrics[0].MType)
+}
+
+func TestQueuePersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue.json")
+
+	q := New(path, 10, time.Hour)
+	q.Push(newCollection(1, 1.0))
+	q.Push(newCollection(2, 2.0))
+
+	restored := New(path, 10, time.Hour)
+	require.Equal(t, 2, restored.Len())
+	batch := restored.Peek()
+	require.NotNil(t, batch)
+	assert.Equal(t, int64(1), *batch.Metrics[0].Delta)
+
+	restored.Pop(batch)
+	assert.Equal(t, 1, New(path, 10, time.Hour).Len())
+}
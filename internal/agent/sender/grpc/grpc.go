@@ -3,11 +3,19 @@ package grpc
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
 
 	"github.com/rs/zerolog/log"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 
+	"github.com/hikjik/go-metrics/internal/agent/sender"
 	"github.com/hikjik/go-metrics/internal/metrics"
 	pb "github.com/hikjik/go-metrics/internal/proto"
 )
@@ -17,10 +25,17 @@ type Sender struct {
 	Client pb.MetricsClient
 }
 
-func New(address string) *Sender {
-	conn, err := grpc.Dial(address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+// New создает Sender, отправляющий метрики на сервер address.
+// Если tlsConfig не nil, соединение с сервером защищается с помощью TLS.
+func New(address string, tlsConfig *tls.Config) *Sender {
+	creds := insecure.NewCredentials()
+	if tlsConfig != nil {
+		creds = credentials.NewTLS(tlsConfig)
+	}
+
+	conn, err := grpc.Dial(address, grpc.WithTransportCredentials(creds))
 	if err != nil {
-		log.Fatal().Err(err)
+		log.Fatal().Err(err).Msg("Failed to dial grpc server")
 	}
 
 	return &Sender{
@@ -29,25 +44,47 @@ func New(address string) *Sender {
 	}
 }
 
-func (s *Sender) Send(ctx context.Context, collection []*metrics.Metric) {
+func (s *Sender) Send(ctx context.Context, collection []*metrics.Metric) (*sender.Result, error) {
 	stream, err := s.Client.PutMetrics(ctx)
 	if err != nil {
-		log.Error().Err(err).Msgf("Failed to open grpc stream")
-		return
+		return nil, fmt.Errorf("failed to open grpc stream: %w", err)
 	}
-	defer func() {
-		if err = stream.CloseSend(); err != nil {
-			log.Error().Err(err).Msg("Failed to close stream")
-		}
-	}()
 
 	for _, metric := range collection {
 		request := pb.PutMetricRequest{
 			Metric: pb.ToPb(metric),
 		}
 
-		if err = stream.Send(&request); err != nil {
-			log.Error().Err(err).Msgf("Failed to send metric %v", metric)
+		if err = stream.Send(&request); errors.Is(err, io.EOF) {
+			// сервер завершил поток, его статус возвращает CloseAndRecv
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to send metric %s: %w", metric.ID, err)
 		}
 	}
+
+	response, err := stream.CloseAndRecv()
+	if permanent(err) {
+		return nil, fmt.Errorf("%w: %v", sender.ErrPermanent, err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to close grpc stream: %w", err)
+	}
+
+	statuses := make([]metrics.Status, 0, len(response.GetStatuses()))
+	for _, status := range response.GetStatuses() {
+		statuses = append(statuses, pb.StatusFromPb(status))
+	}
+	return sender.NewResult(collection, statuses), nil
+}
+
+// permanent возвращает true для ошибок, при которых повторная отправка
+// того же набора метрик будет отклонена снова
+func permanent(err error) bool {
+	switch status.Code(err) {
+	case codes.InvalidArgument, codes.PermissionDenied, codes.Unauthenticated, codes.Unimplemented:
+		return true
+	}
+	return false
 }
@@ -4,12 +4,16 @@ package http
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 
 	"github.com/rs/zerolog/log"
 
+	"github.com/hikjik/go-metrics/internal/agent/sender"
 	"github.com/hikjik/go-metrics/internal/encryption"
 	"github.com/hikjik/go-metrics/internal/encryption/rsa"
 	"github.com/hikjik/go-metrics/internal/metrics"
@@ -17,53 +21,89 @@ import (
 
 type Sender struct {
 	Encrypter encryption.Encrypter
+	Client    *http.Client
 	Address   string
+	Scheme    string
 }
 
-func New(address string, keyPath string) *Sender {
-	encrypter, err := rsa.NewEncrypter(keyPath)
+// New создает Sender, отправляющий метрики на сервер address.
+// Если tlsConfig не nil, метрики отправляются по протоколу https, если не задана схема scheme.
+// Если задан путь keyPath к публичному ключу, тело запроса шифруется в формате format.
+func New(address, scheme string, tlsConfig *tls.Config, keyPath, format string) *Sender {
+	encrypter, err := rsa.NewEncrypter(keyPath, format)
 	if err != nil {
 		log.Fatal().Err(err).Msg("failed to setup rsa encryption")
 	}
 
+	if scheme == "" {
+		scheme = "http"
+		if tlsConfig != nil {
+			scheme = "https"
+		}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+
 	return &Sender{
 		Address:   address,
+		Scheme:    scheme,
 		Encrypter: encrypter,
+		Client: &http.Client{
+			Transport: CustomTransport{transport},
+		},
 	}
 }
 
-func (s *Sender) Send(ctx context.Context, collection []*metrics.Metric) {
+func (s *Sender) Send(ctx context.Context, collection []*metrics.Metric) (*sender.Result, error) {
 	data, err := json.Marshal(collection)
 	if err != nil {
-		log.Warn().Err(err).Msg("Failed to marshal metrics")
-		return
+		return nil, fmt.Errorf("failed to marshal metrics: %w", err)
 	}
 
 	encryptedData, err := s.encryptData(data)
 	if err != nil {
-		log.Warn().Err(err).Msg("Failed to encrypt metrics data")
-		return
+		return nil, fmt.Errorf("failed to encrypt metrics data: %w", err)
 	}
 
-	client := http.Client{
-		Transport: CustomTransport{http.DefaultTransport},
-	}
-	url := fmt.Sprintf("http://%s/updates/", s.Address)
+	url := fmt.Sprintf("%s://%s/updates/", s.Scheme, s.Address)
 	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(encryptedData))
 	if err != nil {
-		log.Warn().Err(err).Msgf("Failed to create req")
-		return
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
 
-	response, err := client.Do(req)
+	response, err := s.Client.Do(req)
 	if err != nil {
-		log.Warn().Err(err).Msg("Failed to post metric")
-		return
+		return nil, fmt.Errorf("failed to post metrics: %w", err)
+	}
+	defer func() {
+		if err = response.Body.Close(); err != nil {
+			log.Warn().Err(err).Msg("Failed to close response body")
+		}
+	}()
+	if permanent(response.StatusCode) {
+		return nil, fmt.Errorf("%w: %s", sender.ErrPermanent, response.Status)
 	}
-	if err = response.Body.Close(); err != nil {
-		log.Warn().Err(err).Msg("Failed to close response body")
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected response status: %s", response.Status)
+	}
+
+	var statuses []metrics.Status
+	if err = json.NewDecoder(response.Body).Decode(&statuses); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return sender.NewResult(collection, statuses), nil
+}
+
+// permanent возвращает true для ошибок клиента, при которых повторная отправка
+// того же запроса будет отклонена снова
+func permanent(statusCode int) bool {
+	switch statusCode {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests:
+		return false
 	}
+	return statusCode >= 400 && statusCode < 500
 }
 
 func (s *Sender) encryptData(data []byte) ([]byte, error) {
@@ -0,0 +1,82 @@
+package http
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hikjik/go-metrics/internal/agent/sender"
+	"github.com/hikjik/go-metrics/internal/metrics"
+)
+
+func TestSendTLS(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/updates/", r.URL.Path)
+
+		var collection []*metrics.Metric
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&collection))
+
+		statuses := make([]metrics.Status, 0, len(collection))
+		for _, m := range collection {
+			statuses = append(statuses, metrics.NewStatus(m, nil))
+		}
+		statuses[len(statuses)-1].Error = "invalid hash"
+		require.NoError(t, json.NewEncoder(w).Encode(statuses))
+	}))
+	defer server.Close()
+
+	tlsConfig := &tls.Config{
+		RootCAs:    server.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs,
+		MinVersion: tls.VersionTLS12,
+	}
+	address := strings.TrimPrefix(server.URL, "https://")
+
+	s := New(address, "", tlsConfig, "", "")
+	require.Equal(t, "https", s.Scheme)
+
+	result, err := s.Send(context.Background(), []*metrics.Metric{
+		metrics.NewCounter("PollCount", 1),
+		metrics.NewGauge("Alloc", 1.5),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Accepted)
+	require.Len(t, result.Rejected, 1)
+	assert.Equal(t, "Alloc", result.Rejected[0].ID)
+
+	_, err = New(address, "", nil, "", "").Send(context.Background(), nil)
+	assert.Error(t, err)
+}
+
+func TestSendPermanentError(t *testing.T) {
+	tests := []struct {
+		name       string
+		statusCode int
+		permanent  bool
+	}{
+		{name: "Bad request", statusCode: http.StatusBadRequest, permanent: true},
+		{name: "Forbidden", statusCode: http.StatusForbidden, permanent: true},
+		{name: "Too many requests", statusCode: http.StatusTooManyRequests, permanent: false},
+		{name: "Internal server error", statusCode: http.StatusInternalServerError, permanent: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.statusCode)
+			}))
+			defer server.Close()
+
+			s := New(strings.TrimPrefix(server.URL, "http://"), "", nil, "", "")
+			_, err := s.Send(context.Background(), []*metrics.Metric{metrics.NewGauge("Alloc", 1.5)})
+			require.Error(t, err)
+			assert.Equal(t, tt.permanent, errors.Is(err, sender.ErrPermanent))
+		})
+	}
+}
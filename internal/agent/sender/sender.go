@@ -3,10 +3,44 @@ package sender
 
 import (
 	"context"
+	"errors"
 
 	"github.com/hikjik/go-metrics/internal/metrics"
 )
 
+// ErrPermanent означает, что сервер отклонил весь набор метрик, например, из-за неверной
+// подписи или запрета доступа, и повторная отправка того же набора не имеет смысла
+var ErrPermanent = errors.New("metrics rejected by server")
+
+// MetricSender отправляет набор метрик на сервер.
+// Ошибка означает, что набор не был принят сервером и его следует отправить повторно,
+// если только она не является ErrPermanent.
 type MetricSender interface {
-	Send(context.Context, []*metrics.Metric)
+	Send(context.Context, []*metrics.Metric) (*Result, error)
+}
+
+// Result содержит результат отправки набора метрик:
+// количество принятых сервером метрик и причины отказа для отклоненных метрик.
+// Отклоненные метрики не имеет смысла отправлять повторно.
+type Result struct {
+	Rejected []metrics.Status
+	Accepted int
+}
+
+// NewResult формирует результат отправки по ответу сервера.
+// Если сервер не вернул статусы метрик, все метрики набора считаются принятыми.
+func NewResult(collection []*metrics.Metric, statuses []metrics.Status) *Result {
+	if len(statuses) == 0 {
+		return &Result{Accepted: len(collection)}
+	}
+
+	result := &Result{}
+	for _, status := range statuses {
+		if status.Accepted() {
+			result.Accepted++
+		} else {
+			result.Rejected = append(result.Rejected, status)
+		}
+	}
+	return result
 }
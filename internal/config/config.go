@@ -4,21 +4,122 @@ package config
 import (
 	"encoding/json"
 	"flag"
+	"fmt"
 	"os"
+	"reflect"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/caarlos0/env/v6"
 	"github.com/rs/zerolog/log"
 )
 
+// TLSConfig содержит настройки TLS.
+// На сервере CAFile задает сертификат центра, которым должны быть подписаны
+// сертификаты агентов; если он не задан, сертификат агента не запрашивается.
+// На агенте CAFile задает сертификат центра, которым подписан сертификат сервера;
+// если он не задан, используются системные сертификаты.
+type TLSConfig struct {
+	CertFile   string `env:"TLS_CERT" json:"tls_cert"`
+	KeyFile    string `env:"TLS_KEY" json:"tls_key"`
+	CAFile     string `env:"TLS_CA" json:"tls_ca"`
+	ServerName string `env:"TLS_SERVER_NAME" json:"tls_server_name"`
+	Enabled    bool   `env:"TLS" json:"tls"`
+}
+
+// SourceConfig содержит настройки источника метрик агента.
+// Если период опроса не задан, используется PollInterval агента.
+type SourceConfig struct {
+	Interval time.Duration `env:"INTERVAL" json:"interval"`
+	Enabled  bool          `env:"ENABLED" json:"enabled"`
+}
+
+// RuntimeSourceConfig содержит настройки источника Runtime.
+// MemStats включает метрики runtime.MemStats, чтение которых останавливает программу.
+type RuntimeSourceConfig struct {
+	SourceConfig
+	MemStats bool `env:"MEMSTATS" json:"memstats"`
+}
+
+// CgroupSourceConfig содержит настройки источника метрик cgroup v2.
+// Paths задает пути cgroup относительно Root; если они не заданы, используется cgroup агента.
+type CgroupSourceConfig struct {
+	Root  string   `env:"ROOT" json:"root"`
+	Paths []string `env:"PATHS" json:"paths"`
+	SourceConfig
+}
+
+// ProcessSourceConfig содержит настройки источника метрик процессов.
+// Правила Rules имеют вид group:kind=value, где kind - name (имя процесса),
+// cmdline (регулярное выражение для командной строки) или pidfile (путь к pid-файлу).
+// Метрики процессов содержат метку pid, поэтому каждый перезапуск процесса
+// добавляет на сервере новые серии, которые не удаляются.
+type ProcessSourceConfig struct {
+	Rules []string `env:"RULES" json:"rules"`
+	SourceConfig
+}
+
+// GoRuntimeSourceConfig содержит настройки источника метрик пакета runtime/metrics.
+// Metrics задает префиксы имен публикуемых метрик, например /gc/ или /sched/latencies:seconds;
+// если они не заданы, публикуются все поддерживаемые метрики.
+// HistogramMode задает представление гистограмм: histogram или summary.
+type GoRuntimeSourceConfig struct {
+	HistogramMode string   `env:"HISTOGRAM_MODE" json:"histogram_mode"`
+	Metrics       []string `env:"METRICS" json:"metrics"`
+	SourceConfig
+}
+
+// ExecSourceConfig описывает внешнюю команду, вывод которой агент публикует как метрики.
+// Format задает формат вывода: simple (строки вида name type value) или prometheus.
+// Если период опроса не задан, используется PollInterval агента,
+// если не задан таймаут - период опроса.
+type ExecSourceConfig struct {
+	Name     string        `json:"name"`
+	Format   string        `json:"format"`
+	Command  []string      `json:"command"`
+	Interval time.Duration `json:"interval"`
+	Timeout  time.Duration `json:"timeout"`
+}
+
+// SourcesConfig содержит настройки источников метрик агента
+type SourcesConfig struct {
+	Exec        []ExecSourceConfig    `env:"EXEC" json:"exec"`
+	Cgroup      CgroupSourceConfig    `envPrefix:"CGROUP_" json:"cgroup"`
+	Process     ProcessSourceConfig   `envPrefix:"PROCESS_" json:"process"`
+	GoRuntime   GoRuntimeSourceConfig `envPrefix:"GO_RUNTIME_" json:"go_runtime"`
+	Runtime     RuntimeSourceConfig   `envPrefix:"RUNTIME_" json:"runtime"`
+	Utilization SourceConfig          `envPrefix:"UTILIZATION_" json:"utilization"`
+	Disk        SourceConfig          `envPrefix:"DISK_" json:"disk"`
+	Filesystem  SourceConfig          `envPrefix:"FILESYSTEM_" json:"filesystem"`
+	Network     SourceConfig          `envPrefix:"NETWORK_" json:"network"`
+	Load        SourceConfig          `envPrefix:"LOAD_" json:"load"`
+	Uptime      SourceConfig          `envPrefix:"UPTIME_" json:"uptime"`
+	Swap        SourceConfig          `envPrefix:"SWAP_" json:"swap"`
+}
+
 // AgentConfig содержит настройки агента по сбору метрик
 type AgentConfig struct {
-	Address        string        `env:"ADDRESS" json:"address"`
-	GRPCAddress    string        `env:"GRPC_ADDRESS" json:"grpc_address"`
-	SignatureKey   string        `env:"KEY" json:"key"`
-	PublicKeyPath  string        `env:"CRYPTO_KEY" json:"crypto_key"`
-	PollInterval   time.Duration `env:"POLL_INTERVAL" json:"poll_interval"`
-	ReportInterval time.Duration `env:"REPORT_INTERVAL" json:"report_interval"`
+	Labels         map[string]string `env:"LABELS" json:"labels"`
+	Address        string            `env:"ADDRESS" json:"address"`
+	GRPCAddress    string            `env:"GRPC_ADDRESS" json:"grpc_address"`
+	SignatureKey   string            `env:"KEY" json:"key"`
+	PublicKeyPath  string            `env:"CRYPTO_KEY" json:"crypto_key"`
+	CryptoFormat   string            `env:"CRYPTO_FORMAT" json:"crypto_format"`
+	AgentID        string            `env:"AGENT_ID" json:"agent_id"`
+	QueueFile      string            `env:"QUEUE_FILE" json:"queue_file"`
+	Scheme         string            `env:"SCHEME" json:"scheme"`
+	MetricsAddress string            `env:"METRICS_ADDRESS" json:"metrics_address"`
+	IngestSocket   string            `env:"INGEST_SOCKET" json:"ingest_socket"`
+	IngestAddress  string            `env:"INGEST_UDP_ADDRESS" json:"ingest_udp_address"`
+	GRPCTLS        TLSConfig         `envPrefix:"GRPC_" json:"grpc_tls"`
+	HTTPTLS        TLSConfig         `envPrefix:"HTTP_" json:"http_tls"`
+	Sources        SourcesConfig     `envPrefix:"SOURCE_" json:"sources"`
+	PollInterval   time.Duration     `env:"POLL_INTERVAL" json:"poll_interval"`
+	ReportInterval time.Duration     `env:"REPORT_INTERVAL" json:"report_interval"`
+	QueueMaxAge    time.Duration     `env:"QUEUE_MAX_AGE" json:"queue_max_age"`
+	QueueSize      int               `env:"QUEUE_SIZE" json:"queue_size"`
+	DisablePush    bool              `env:"DISABLE_PUSH" json:"disable_push"`
 }
 
 // StorageConfig содержит настройки хранилища метрик
@@ -27,16 +128,26 @@ type StorageConfig struct {
 	DatabaseDNS   string        `env:"DATABASE_DSN" json:"database_dsn"`
 	StoreInterval time.Duration `env:"STORE_INTERVAL" json:"store_interval"`
 	Restore       bool          `env:"RESTORE" json:"restore"`
+	HistorySize   int           `env:"HISTORY_SIZE" json:"history_size"`
 }
 
 // ServerConfig содержит настройки сервера по сбору рантайм-метрик
 type ServerConfig struct {
-	Address           string `env:"ADDRESS" json:"address"`
-	GRPCAddress       string `env:"GRPC_ADDRESS" json:"grpc_address"`
-	SignatureKey      string `env:"KEY" json:"key"`
-	EncryptionKeyPath string `env:"CRYPTO_KEY" json:"crypto_key"`
-	TrustedSubnet     string `env:"TRUSTED_SUBNET" json:"trusted_subnet"`
+	Address           string    `env:"ADDRESS" json:"address"`
+	GRPCAddress       string    `env:"GRPC_ADDRESS" json:"grpc_address"`
+	StatsDAddress     string    `env:"STATSD_ADDRESS" json:"statsd_address"`
+	SignatureKey      string    `env:"KEY" json:"key"`
+	EncryptionKeyPath string    `env:"CRYPTO_KEY" json:"crypto_key"`
+	TrustedSubnet     string    `env:"TRUSTED_SUBNET" json:"trusted_subnet"`
+	AllowedSubnets    []string  `env:"ALLOWED_SUBNETS" json:"allowed_subnets"`
+	DeniedSubnets     []string  `env:"DENIED_SUBNETS" json:"denied_subnets"`
+	TrustedProxies    []string  `env:"TRUSTED_PROXIES" json:"trusted_proxies"`
+	InfluxCounters    []string  `env:"INFLUX_COUNTER_FIELDS" json:"influx_counter_fields"`
+	ScrapeTargets     []string  `env:"SCRAPE_TARGETS" json:"scrape_targets"`
+	GRPCTLS           TLSConfig `envPrefix:"GRPC_" json:"grpc_tls"`
+	HTTPTLS           TLSConfig `envPrefix:"HTTP_" json:"http_tls"`
 	StorageConfig     StorageConfig
+	ScrapeInterval    time.Duration `env:"SCRAPE_INTERVAL" json:"scrape_interval"`
 }
 
 // GetAgentConfig возвращает настройки AgentConfig
@@ -50,6 +161,51 @@ func GetAgentConfig() AgentConfig {
 	flag.DurationVar(&config.ReportInterval, "r", time.Second*10, "Report interval, sec")
 	flag.StringVar(&config.SignatureKey, "k", "", "HMAC key")
 	flag.StringVar(&config.PublicKeyPath, "crypto-key", "", "Path to public RSA key")
+	flag.StringVar(&config.CryptoFormat, "crypto-format", "legacy",
+		"Encryption format: legacy or hybrid, switch to hybrid only after all servers are upgraded to support it")
+	flag.StringVar(&config.AgentID, "id", "", "Agent ID, host name by default")
+	flag.Var(&labelsValue{labels: &config.Labels}, "labels", "Metric labels: name1:value1,name2:value2")
+	flag.StringVar(&config.QueueFile, "queue-file", "", "Path to unsent metrics queue file, without it counter increments not yet delivered are lost on restart")
+	flag.IntVar(&config.QueueSize, "queue-size", 100, "Max number of unsent metric batches")
+	flag.DurationVar(&config.QueueMaxAge, "queue-max-age", time.Hour, "Max age of unsent gauge values")
+	flag.BoolVar(&config.GRPCTLS.Enabled, "grpc-tls", false, "Use TLS for GRPC connection")
+	flag.StringVar(&config.GRPCTLS.CAFile, "grpc-tls-ca", "", "Path to GRPC server CA certificate")
+	flag.StringVar(&config.GRPCTLS.CertFile, "grpc-tls-cert", "", "Path to GRPC client certificate")
+	flag.StringVar(&config.GRPCTLS.KeyFile, "grpc-tls-key", "", "Path to GRPC client private key")
+	flag.StringVar(&config.GRPCTLS.ServerName, "grpc-tls-server-name", "", "GRPC server name for certificate verification")
+	flag.StringVar(&config.Scheme, "scheme", "", "HTTP server scheme: http or https, https if HTTP TLS is enabled by default")
+	flag.BoolVar(&config.HTTPTLS.Enabled, "http-tls", false, "Use TLS for HTTP connection")
+	flag.StringVar(&config.HTTPTLS.CAFile, "http-tls-ca", "", "Path to HTTP server CA certificate")
+	flag.StringVar(&config.HTTPTLS.CertFile, "http-tls-cert", "", "Path to HTTP client certificate")
+	flag.StringVar(&config.HTTPTLS.KeyFile, "http-tls-key", "", "Path to HTTP client private key")
+	flag.StringVar(&config.HTTPTLS.ServerName, "http-tls-server-name", "", "HTTP server name for certificate verification")
+	sourceFlags("runtime", &config.Sources.Runtime.SourceConfig, true)
+	flag.BoolVar(&config.Sources.Runtime.MemStats, "source-runtime-memstats", false,
+		"Collect runtime.MemStats metrics, reading them stops the program")
+	sourceFlags("utilization", &config.Sources.Utilization, true)
+	sourceFlags("disk", &config.Sources.Disk, true)
+	sourceFlags("filesystem", &config.Sources.Filesystem, true)
+	sourceFlags("network", &config.Sources.Network, true)
+	sourceFlags("load", &config.Sources.Load, true)
+	sourceFlags("uptime", &config.Sources.Uptime, true)
+	sourceFlags("swap", &config.Sources.Swap, true)
+	sourceFlags("cgroup", &config.Sources.Cgroup.SourceConfig, false)
+	flag.StringVar(&config.Sources.Cgroup.Root, "source-cgroup-root", "/sys/fs/cgroup", "Mount point of cgroup v2 hierarchy")
+	flag.Var(&listValue{values: &config.Sources.Cgroup.Paths}, "source-cgroup-paths",
+		"Cgroup paths relative to hierarchy root: path1,path2, agent cgroup by default")
+	sourceFlags("go-runtime", &config.Sources.GoRuntime.SourceConfig, true)
+	flag.Var(&listValue{values: &config.Sources.GoRuntime.Metrics}, "source-go-runtime-metrics",
+		"Name prefixes of runtime/metrics metrics: /gc/,/sched/latencies:seconds, all metrics by default")
+	flag.StringVar(&config.Sources.GoRuntime.HistogramMode, "source-go-runtime-histogram-mode", "histogram",
+		"Representation of runtime/metrics histograms: histogram or summary")
+	sourceFlags("process", &config.Sources.Process.SourceConfig, false)
+	flag.Var(&listValue{values: &config.Sources.Process.Rules}, "source-process-rules",
+		"Process selection rules: group1:name=nginx,group2:cmdline=regexp,group3:pidfile=path, "+
+			"each restart of a selected process adds new series labeled with its pid")
+	flag.StringVar(&config.MetricsAddress, "metrics-address", "", "Address of local HTTP endpoint serving agent metrics")
+	flag.BoolVar(&config.DisablePush, "disable-push", false, "Do not send metrics to server")
+	flag.StringVar(&config.IngestSocket, "ingest-socket", "", "Path to unix socket accepting application metrics")
+	flag.StringVar(&config.IngestAddress, "ingest-udp", "", "Localhost UDP address accepting application metrics")
 	flag.StringVar(&path, "c", "", "Path to json config file")
 	flag.StringVar(&path, "config", "", "Path to json config file")
 	flag.Parse()
@@ -61,7 +217,7 @@ func GetAgentConfig() AgentConfig {
 	// second call for correct priority
 	flag.Parse()
 
-	if err := env.Parse(&config); err != nil {
+	if err := env.ParseWithFuncs(&config, parsers); err != nil {
 		log.Fatal().Err(err).Msg("Failed to parse agent config")
 	}
 
@@ -75,13 +231,29 @@ func GetServerConfig() ServerConfig {
 
 	flag.StringVar(&config.Address, "a", "127.0.0.1:8080", "Server Address")
 	flag.StringVar(&config.GRPCAddress, "g", "", "Server GRPC Address")
+	flag.StringVar(&config.StatsDAddress, "statsd", "", "Server StatsD UDP and TCP Address")
 	flag.StringVar(&config.SignatureKey, "k", "", "HMAC key")
 	flag.StringVar(&config.TrustedSubnet, "t", "", "Trusted subnet")
+	flag.Var(&listValue{values: &config.AllowedSubnets}, "allowed-subnets", "Allowed subnets: cidr1,cidr2")
+	flag.Var(&listValue{values: &config.DeniedSubnets}, "denied-subnets", "Denied subnets: cidr1,cidr2")
+	flag.Var(&listValue{values: &config.TrustedProxies}, "trusted-proxies", "Trusted proxy subnets: cidr1,cidr2")
+	flag.Var(&listValue{values: &config.InfluxCounters}, "influx-counter-fields",
+		"Patterns of InfluxDB integer fields stored as counters: pattern1,pattern2")
+	flag.Var(&listValue{values: &config.ScrapeTargets}, "scrape-targets",
+		"Prometheus targets to scrape: url1,url2 or host:port, /metrics path by default")
+	flag.DurationVar(&config.ScrapeInterval, "scrape-interval", time.Second*15, "Scrape interval")
 	flag.StringVar(&config.StorageConfig.StoreFile, "f", "/tmp/devops-metrics-db.json", "Store File")
 	flag.DurationVar(&config.StorageConfig.StoreInterval, "i", time.Second*300, "Store Interval")
 	flag.BoolVar(&config.StorageConfig.Restore, "r", true, "Restore After Start")
 	flag.StringVar(&config.StorageConfig.DatabaseDNS, "d", "", "Database DNS")
+	flag.IntVar(&config.StorageConfig.HistorySize, "history-size", 1000, "History Size")
 	flag.StringVar(&config.EncryptionKeyPath, "crypto-key", "", "Path to private RSA key")
+	flag.StringVar(&config.GRPCTLS.CertFile, "grpc-tls-cert", "", "Path to GRPC server certificate")
+	flag.StringVar(&config.GRPCTLS.KeyFile, "grpc-tls-key", "", "Path to GRPC server private key")
+	flag.StringVar(&config.GRPCTLS.CAFile, "grpc-tls-client-ca", "", "Path to CA certificate for GRPC client verification")
+	flag.StringVar(&config.HTTPTLS.CertFile, "http-tls-cert", "", "Path to HTTP server certificate")
+	flag.StringVar(&config.HTTPTLS.KeyFile, "http-tls-key", "", "Path to HTTP server private key")
+	flag.StringVar(&config.HTTPTLS.CAFile, "http-tls-client-ca", "", "Path to CA certificate for HTTP client verification")
 	flag.StringVar(&path, "c", "", "Path to json config file")
 	flag.StringVar(&path, "config", "", "Path to json config file")
 	flag.Parse()
@@ -93,13 +265,96 @@ func GetServerConfig() ServerConfig {
 	// second call for correct priority
 	flag.Parse()
 
-	if err := env.Parse(&config); err != nil {
+	if err := env.ParseWithFuncs(&config, parsers); err != nil {
 		log.Fatal().Err(err).Msg("Failed to parse server config")
 	}
 
 	return config
 }
 
+// sourceFlags регистрирует флаги source-<name> и source-<name>-interval,
+// включающие источник метрик name и задающие период его опроса.
+// По умолчанию источник включен, если enabled равен true.
+func sourceFlags(name string, cfg *SourceConfig, enabled bool) {
+	flag.BoolVar(&cfg.Enabled, "source-"+name, enabled, fmt.Sprintf("Collect %s metrics", name))
+	flag.DurationVar(&cfg.Interval, "source-"+name+"-interval", 0,
+		fmt.Sprintf("Poll interval of %s metrics, poll interval by default", name))
+}
+
+// parsers содержит функции разбора переменных окружения для типов,
+// не поддерживаемых пакетом env
+var parsers = map[reflect.Type]env.ParserFunc{
+	reflect.TypeOf(map[string]string{}): func(value string) (interface{}, error) {
+		return parseLabels(value)
+	},
+	reflect.TypeOf([]ExecSourceConfig{}): func(value string) (interface{}, error) {
+		var commands []ExecSourceConfig
+		if err := json.Unmarshal([]byte(value), &commands); err != nil {
+			return nil, err
+		}
+		return commands, nil
+	},
+}
+
+// parseLabels разбирает набор меток вида name1:value1,name2:value2
+func parseLabels(value string) (map[string]string, error) {
+	labels := make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		if pair == "" {
+			continue
+		}
+		i := strings.Index(pair, ":")
+		if i <= 0 {
+			return nil, fmt.Errorf("invalid label: %s", pair)
+		}
+		labels[pair[:i]] = pair[i+1:]
+	}
+	return labels, nil
+}
+
+// labelsValue позволяет задавать набор меток флагом командной строки
+type labelsValue struct {
+	labels *map[string]string
+}
+
+func (v *labelsValue) String() string {
+	if v.labels == nil {
+		return ""
+	}
+	pairs := make([]string, 0, len(*v.labels))
+	for name, value := range *v.labels {
+		pairs = append(pairs, name+":"+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+func (v *labelsValue) Set(value string) error {
+	labels, err := parseLabels(value)
+	if err != nil {
+		return err
+	}
+	*v.labels = labels
+	return nil
+}
+
+// listValue позволяет задавать список значений через запятую флагом командной строки
+type listValue struct {
+	values *[]string
+}
+
+func (v *listValue) String() string {
+	if v.values == nil {
+		return ""
+	}
+	return strings.Join(*v.values, ",")
+}
+
+func (v *listValue) Set(value string) error {
+	*v.values = strings.Split(value, ",")
+	return nil
+}
+
 func parseConfigJSON(cfg interface{}, path string) error {
 	if path != "" {
 		data, err := os.ReadFile(path)
@@ -0,0 +1,116 @@
+package rsa
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Форматы шифрования данных
+const (
+	// FormatHybrid данные шифруются алгоритмом AES-256-GCM со случайным ключом,
+	// ключ шифруется алгоритмом RSA-OAEP и передается вместе с данными
+	FormatHybrid = "hybrid"
+	// FormatLegacy данные разбиваются на блоки, каждый из которых шифруется алгоритмом RSA-OAEP
+	FormatLegacy = "legacy"
+)
+
+// Заголовок конверта с зашифрованными данными
+const (
+	envelopeMagic   = "GMEV"
+	envelopeVersion = 1
+	aesKeySize      = 32
+)
+
+// errNotEnvelope данные не являются конвертом формата FormatHybrid
+var errNotEnvelope = errors.New("not an encrypted envelope")
+
+// sealEnvelope шифрует данные в формате FormatHybrid.
+//
+// Формат конверта:
+//
+//	magic (4 байта) | version (1 байт) | длина ключа (2 байта, big endian) |
+//	ключ AES, зашифрованный RSA-OAEP | nonce AES-GCM | данные, зашифрованные AES-GCM
+//
+// Заголовок magic | version используется как дополнительные данные AES-GCM.
+func sealEnvelope(publicKey *rsa.PublicKey, plaintext []byte) ([]byte, error) {
+	key := make([]byte, aesKeySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, err
+	}
+
+	encryptedKey, err := rsa.EncryptOAEP(sha512.New(), rand.Reader, publicKey, key, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	header := envelopeHeader()
+	envelope := make([]byte, 0, len(header)+2+len(encryptedKey)+len(nonce)+len(plaintext)+gcm.Overhead())
+	envelope = append(envelope, header...)
+	envelope = append(envelope, byte(len(encryptedKey)>>8), byte(len(encryptedKey)))
+	envelope = append(envelope, encryptedKey...)
+	envelope = append(envelope, nonce...)
+	return gcm.Seal(envelope, nonce, plaintext, header), nil
+}
+
+// openEnvelope расшифровывает данные в формате FormatHybrid.
+// Если данные не начинаются с заголовка конверта, возвращается ошибка errNotEnvelope.
+func openEnvelope(privateKey *rsa.PrivateKey, envelope []byte) ([]byte, error) {
+	header := envelopeHeader()
+	if !bytes.HasPrefix(envelope, header) {
+		return nil, errNotEnvelope
+	}
+	data := envelope[len(header):]
+
+	if len(data) < 2 {
+		return nil, fmt.Errorf("truncated envelope")
+	}
+	keySize := int(binary.BigEndian.Uint16(data))
+	data = data[2:]
+	if len(data) < keySize {
+		return nil, fmt.Errorf("truncated envelope")
+	}
+
+	key, err := rsa.DecryptOAEP(sha512.New(), rand.Reader, privateKey, data[:keySize], nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt envelope key: %w", err)
+	}
+	data = data[keySize:]
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, fmt.Errorf("truncated envelope")
+	}
+	return gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], header)
+}
+
+func envelopeHeader() []byte {
+	return append([]byte(envelopeMagic), envelopeVersion)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -1,22 +1,39 @@
-// Package rsa предназначен для шифрования данных с помощью алгоритма RSA
+// Package rsa предназначен для шифрования данных с помощью алгоритма RSA.
+// Данные шифруются в формате FormatHybrid или FormatLegacy,
+// Decrypter принимает данные в обоих форматах.
 package rsa
 
 import (
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/sha512"
+	"errors"
+	"fmt"
 	"io/ioutil"
 )
 
 type Encrypter struct {
 	publicKey *rsa.PublicKey
+	format    string
 }
 
-func NewEncrypter(path string) (*Encrypter, error) {
+// NewEncrypter создает Encrypter с публичным ключом из файла path,
+// шифрующий данные в формате format. Пустой format означает FormatLegacy:
+// формат FormatHybrid понимают только обновленные серверы, поэтому его
+// следует включать после обновления всех серверов.
+func NewEncrypter(path string, format string) (*Encrypter, error) {
 	if path == "" {
 		return nil, nil
 	}
 
+	switch format {
+	case "":
+		format = FormatLegacy
+	case FormatHybrid, FormatLegacy:
+	default:
+		return nil, fmt.Errorf("unknown encryption format: %s", format)
+	}
+
 	keyData, err := ioutil.ReadFile(path)
 	if err != nil {
 		return nil, err
@@ -26,14 +43,21 @@ func NewEncrypter(path string) (*Encrypter, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &Encrypter{publicKey: publicKey}, nil
+	return &Encrypter{publicKey: publicKey, format: format}, nil
 }
 
 func (e *Encrypter) Encrypt(plaintext []byte) ([]byte, error) {
 	if e == nil {
 		return plaintext, nil
 	}
+	if e.format == FormatLegacy {
+		return e.encryptLegacy(plaintext)
+	}
+	return sealEnvelope(e.publicKey, plaintext)
+}
 
+// encryptLegacy шифрует данные в формате FormatLegacy
+func (e *Encrypter) encryptLegacy(plaintext []byte) ([]byte, error) {
 	hash := sha512.New()
 	step := e.publicKey.Size() - 2*hash.Size() - 2
 	var encryptedBytes []byte
@@ -76,11 +100,25 @@ func NewDecrypter(path string) (*Decrypter, error) {
 	return &Decrypter{privateKey: privateKey}, nil
 }
 
+// Decrypt расшифровывает данные в формате FormatHybrid или FormatLegacy
 func (d *Decrypter) Decrypt(ciphertext []byte) ([]byte, error) {
 	if d == nil {
 		return ciphertext, nil
 	}
 
+	plaintext, err := openEnvelope(d.privateKey, ciphertext)
+	if err == nil {
+		return plaintext, nil
+	}
+	// данные в формате FormatLegacy могут случайно начинаться с заголовка конверта
+	if !errors.Is(err, errNotEnvelope) && len(ciphertext)%d.privateKey.PublicKey.Size() != 0 {
+		return nil, err
+	}
+	return d.decryptLegacy(ciphertext)
+}
+
+// decryptLegacy расшифровывает данные в формате FormatLegacy
+func (d *Decrypter) decryptLegacy(ciphertext []byte) ([]byte, error) {
 	hash := sha512.New()
 	step := d.privateKey.PublicKey.Size()
 	var decryptedBytes []byte
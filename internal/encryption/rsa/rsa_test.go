@@ -1,6 +1,7 @@
 package rsa
 
 import (
+	"bytes"
 	"crypto/rand"
 	"crypto/rsa"
 	"testing"
@@ -12,16 +13,53 @@ func TestRSAEncryption(t *testing.T) {
 	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
 	require.NoError(t, err)
 
-	plaintext := []byte("secret message")
+	tests := []struct {
+		name      string
+		format    string
+		plaintext []byte
+	}{
+		{name: "Hybrid format", format: FormatHybrid, plaintext: []byte("secret message")},
+		{name: "Hybrid format large message", format: FormatHybrid, plaintext: bytes.Repeat([]byte("secret"), 1000)},
+		{name: "Hybrid format empty message", format: FormatHybrid, plaintext: []byte{}},
+		{name: "Legacy format", format: FormatLegacy, plaintext: []byte("secret message")},
+		{name: "Legacy format large message", format: FormatLegacy, plaintext: bytes.Repeat([]byte("secret"), 1000)},
+	}
 
-	e := &Encrypter{publicKey: &privateKey.PublicKey}
 	d := &Decrypter{privateKey: privateKey}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &Encrypter{publicKey: &privateKey.PublicKey, format: tt.format}
 
-	ciphertext, err := e.Encrypt(plaintext)
+			ciphertext, err := e.Encrypt(tt.plaintext)
+			require.NoError(t, err)
+			require.Equal(t, tt.format == FormatHybrid, bytes.HasPrefix(ciphertext, envelopeHeader()))
+
+			plaintextDecrypted, err := d.Decrypt(ciphertext)
+			require.NoError(t, err)
+			require.Equal(t, string(tt.plaintext), string(plaintextDecrypted))
+		})
+	}
+}
+
+func TestRSADecryptionTampered(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
 	require.NoError(t, err)
 
-	plaintextDecrypted, err := d.Decrypt(ciphertext)
+	e := &Encrypter{publicKey: &privateKey.PublicKey, format: FormatHybrid}
+	d := &Decrypter{privateKey: privateKey}
+
+	ciphertext, err := e.Encrypt([]byte("secret message"))
 	require.NoError(t, err)
 
-	require.Equal(t, plaintext, plaintextDecrypted)
+	ciphertext[len(ciphertext)-1] ^= 0xff
+	_, err = d.Decrypt(ciphertext)
+	require.Error(t, err)
+
+	_, err = d.Decrypt(ciphertext[:len(envelopeHeader())+1])
+	require.Error(t, err)
+}
+
+func TestNewEncrypterUnknownFormat(t *testing.T) {
+	_, err := NewEncrypter("key.pem", "unknown")
+	require.Error(t, err)
 }
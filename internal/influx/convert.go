@@ -0,0 +1,98 @@
+package influx
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/hikjik/go-metrics/internal/metrics"
+)
+
+// Converter преобразует точки line protocol в метрики.
+//
+// Каждое поле точки становится метрикой с именем measurement_field, теги точки - метками метрики.
+// Целочисленные поля, имена метрик которых соответствуют одному из шаблонов counters
+// (в формате path.Match), считаются накопленными значениями счетчиков
+// и передаются в хранилище в виде приращений. Остальные числовые и логические поля
+// становятся метриками типа GaugeType, строковые поля пропускаются.
+// Метки времени точек не учитываются: сохраняются текущие значения метрик.
+//
+// Приращения счетчиков отсчитываются от значений, полученных после запуска сервера:
+// первое значение каждого счетчика только запоминается.
+type Converter struct {
+	tracker  *metrics.DeltaTracker
+	counters []string
+}
+
+// NewConverter создает Converter с шаблонами имен счетчиков counters
+func NewConverter(counters []string) (*Converter, error) {
+	for _, pattern := range counters {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid counter pattern %s: %w", pattern, err)
+		}
+	}
+	return &Converter{
+		tracker:  metrics.NewCumulativeTracker(),
+		counters: counters,
+	}, nil
+}
+
+// Write преобразует точки в метрики и передает их в put.
+// Если put завершился с ошибкой, повторная запись тех же точек даст те же приращения счетчиков.
+func (c *Converter) Write(points []Point, put func([]*metrics.Metric) error) error {
+	return c.tracker.Apply(c.convert(points), put)
+}
+
+// convert преобразует точки в метрики с накопленными значениями счетчиков
+func (c *Converter) convert(points []Point) []*metrics.Metric {
+	var collection []*metrics.Metric
+	for _, point := range points {
+		labels := labelsFromTags(point.Tags)
+		for _, field := range point.Fields {
+			id := point.Measurement + "_" + field.Key
+
+			var m *metrics.Metric
+			switch {
+			case field.Type == StringField:
+				continue
+			case field.Type == IntegerField && c.isCounter(id):
+				m = metrics.NewCounter(id, int64(field.Value))
+			default:
+				m = metrics.NewGauge(id, field.Value)
+			}
+			m.Labels = labels
+			collection = append(collection, m)
+		}
+	}
+	return collection
+}
+
+func (c *Converter) isCounter(id string) bool {
+	for _, pattern := range c.counters {
+		if ok, _ := path.Match(pattern, id); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// labelsFromTags преобразует теги в метки, заменяя недопустимые в именах меток символы на '_'
+func labelsFromTags(tags map[string]string) map[string]string {
+	if len(tags) == 0 {
+		return nil
+	}
+	labels := make(map[string]string, len(tags))
+	for key, value := range tags {
+		name := strings.Map(func(r rune) rune {
+			if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' {
+				return r
+			}
+			return '_'
+		}, key)
+		if name[0] >= '0' && name[0] <= '9' {
+			name = "_" + name
+		}
+		labels[name] = value
+	}
+	return labels
+}
@@ -0,0 +1,49 @@
+package influx
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hikjik/go-metrics/internal/metrics"
+)
+
+func TestConverter(t *testing.T) {
+	c, err := NewConverter([]string{"net_bytes_*"})
+	require.NoError(t, err)
+
+	points, err := Parse([]byte(
+		"net,interface=eth0,1st-tag=x bytes_recv=100i,packets=5i,up=t,name=\"eth0\"\n"+
+			"cpu usage=1.5\n"), "")
+	require.NoError(t, err)
+
+	labels := map[string]string{"interface": "eth0", "_1st_tag": "x"}
+	packets := metrics.NewGauge("net_packets", 5)
+	packets.Labels = labels
+	up := metrics.NewGauge("net_up", 1)
+	up.Labels = labels
+	var collection []*metrics.Metric
+	put := func(c []*metrics.Metric) error {
+		collection = c
+		return nil
+	}
+	require.NoError(t, c.Write(points, put))
+	assert.Equal(t, []*metrics.Metric{packets, up, metrics.NewGauge("cpu_usage", 1.5)}, collection)
+
+	points, err = Parse([]byte("net,interface=eth0,1st-tag=x bytes_recv=130i"), "")
+	require.NoError(t, err)
+	errPut := errors.New("put failed")
+	assert.ErrorIs(t, c.Write(points, func([]*metrics.Metric) error { return errPut }), errPut)
+
+	require.NoError(t, c.Write(points, put))
+	counter := metrics.NewCounter("net_bytes_recv", 30)
+	counter.Labels = labels
+	assert.Equal(t, []*metrics.Metric{counter}, collection)
+}
+
+func TestNewConverterInvalidPattern(t *testing.T) {
+	_, err := NewConverter([]string{"net_["})
+	assert.Error(t, err)
+}
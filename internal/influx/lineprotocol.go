@@ -0,0 +1,228 @@
+// Package influx предназначен для разбора метрик в формате InfluxDB line protocol
+// и их преобразования в метрики metrics.Metric.
+package influx
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Типы значений полей
+const (
+	FloatField = iota
+	IntegerField
+	BooleanField
+	StringField
+)
+
+// Точность временных меток
+const (
+	PrecisionNanoseconds  = "ns"
+	PrecisionMicroseconds = "us"
+	PrecisionMilliseconds = "ms"
+	PrecisionSeconds      = "s"
+)
+
+// Ограничения размера запроса. Telegraf по умолчанию отправляет не более
+// тысячи точек в запросе, что значительно меньше этих значений.
+const (
+	// MaxRequestSize максимальный размер тела запроса в том виде, в котором оно передается
+	MaxRequestSize = 16 << 20
+	// MaxDecodedSize максимальный размер тела запроса после распаковки gzip
+	MaxDecodedSize = 64 << 20
+)
+
+// ErrInvalidLine ошибка разбора строки в формате line protocol
+var ErrInvalidLine = errors.New("invalid line protocol")
+
+// Field содержит значение поля точки. Для полей типа StringField значение Value не задано,
+// для полей типа BooleanField значение равно 1 или 0.
+type Field struct {
+	Key   string
+	Value float64
+	Type  int
+}
+
+// Point содержит точку данных measurement,tag=value field=value timestamp
+type Point struct {
+	Timestamp   time.Time
+	Tags        map[string]string
+	Measurement string
+	Fields      []Field
+}
+
+// Parse разбирает набор строк в формате line protocol.
+// Временные метки интерпретируются с точностью precision, по умолчанию в наносекундах.
+func Parse(data []byte, precision string) ([]Point, error) {
+	var unit time.Duration
+	switch precision {
+	case "", PrecisionNanoseconds:
+		unit = time.Nanosecond
+	case PrecisionMicroseconds:
+		unit = time.Microsecond
+	case PrecisionMilliseconds:
+		unit = time.Millisecond
+	case PrecisionSeconds:
+		unit = time.Second
+	default:
+		return nil, fmt.Errorf("unknown precision: %s", precision)
+	}
+
+	var points []Point
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(data)+1)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		point, err := parseLine(line, unit)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		points = append(points, point)
+	}
+	return points, scanner.Err()
+}
+
+func parseLine(line string, unit time.Duration) (Point, error) {
+	var point Point
+
+	measurement, i := scan(line, 0, ", ", false)
+	if measurement == "" {
+		return point, fmt.Errorf("%w: missing measurement", ErrInvalidLine)
+	}
+	point.Measurement = unescape(measurement)
+
+	for i < len(line) && line[i] == ',' {
+		var tag string
+		tag, i = scan(line, i+1, ", ", false)
+		key, value, ok := splitPair(tag)
+		if !ok {
+			return point, fmt.Errorf("%w: invalid tag %s", ErrInvalidLine, tag)
+		}
+		if point.Tags == nil {
+			point.Tags = make(map[string]string)
+		}
+		point.Tags[key] = value
+	}
+
+	if i >= len(line) || line[i] != ' ' {
+		return point, fmt.Errorf("%w: missing fields", ErrInvalidLine)
+	}
+	for {
+		var pair string
+		pair, i = scan(line, i+1, ", ", true)
+		field, err := parseField(pair)
+		if err != nil {
+			return point, err
+		}
+		point.Fields = append(point.Fields, field)
+
+		if i >= len(line) || line[i] != ',' {
+			break
+		}
+	}
+
+	if timestamp := strings.TrimSpace(line[i:]); timestamp != "" {
+		ts, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil {
+			return point, fmt.Errorf("%w: invalid timestamp %s", ErrInvalidLine, timestamp)
+		}
+		point.Timestamp = time.Unix(0, ts*int64(unit))
+	}
+	return point, nil
+}
+
+func parseField(pair string) (Field, error) {
+	key, value, ok := splitPair(pair)
+	if !ok || value == "" {
+		return Field{}, fmt.Errorf("%w: invalid field %s", ErrInvalidLine, pair)
+	}
+
+	field := Field{Key: key}
+	var err error
+	switch {
+	case strings.HasPrefix(value, `"`):
+		if len(value) < 2 || !strings.HasSuffix(value, `"`) {
+			err = ErrInvalidLine
+		}
+		field.Type = StringField
+	case strings.HasSuffix(value, "i"):
+		var v int64
+		v, err = strconv.ParseInt(value[:len(value)-1], 10, 64)
+		field.Type, field.Value = IntegerField, float64(v)
+	case strings.HasSuffix(value, "u"):
+		var v uint64
+		v, err = strconv.ParseUint(value[:len(value)-1], 10, 64)
+		field.Type, field.Value = IntegerField, float64(v)
+	default:
+		switch value {
+		case "t", "T", "true", "True", "TRUE":
+			field.Type, field.Value = BooleanField, 1
+		case "f", "F", "false", "False", "FALSE":
+			field.Type, field.Value = BooleanField, 0
+		default:
+			field.Type = FloatField
+			field.Value, err = strconv.ParseFloat(value, 64)
+		}
+	}
+	if err != nil || math.IsNaN(field.Value) || math.IsInf(field.Value, 0) {
+		return Field{}, fmt.Errorf("%w: invalid field value %s", ErrInvalidLine, pair)
+	}
+	return field, nil
+}
+
+// scan возвращает часть строки line, начиная с позиции start, до первого неэкранированного
+// символа из stops, и позицию этого символа. Если quotes равен true,
+// символы stops внутри двойных кавычек не учитываются.
+func scan(line string, start int, stops string, quotes bool) (string, int) {
+	quoted := false
+	i := start
+	for ; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case c == '\\':
+			i++
+		case c == '"' && quotes:
+			quoted = !quoted
+		case !quoted && strings.IndexByte(stops, c) >= 0:
+			return line[start:i], i
+		}
+	}
+	if i > len(line) {
+		i = len(line)
+	}
+	return line[start:i], i
+}
+
+// splitPair разбирает пару key=value, разделенную первым неэкранированным символом '='
+func splitPair(pair string) (string, string, bool) {
+	key, i := scan(pair, 0, "=", false)
+	if key == "" || i >= len(pair) {
+		return "", "", false
+	}
+	return unescape(key), unescape(pair[i+1:]), true
+}
+
+// unescape удаляет символы экранирования '\' перед запятыми, пробелами, знаками '=' и кавычками
+func unescape(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) && strings.IndexByte(`, ="\`, s[i+1]) >= 0 {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
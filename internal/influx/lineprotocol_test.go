@@ -0,0 +1,82 @@
+package influx
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      string
+		precision string
+		want      []Point
+		wantErr   bool
+	}{
+		{
+			name: "Point with tags and timestamp",
+			data: "cpu,host=server01,region=us-west usage_idle=92.5,usage_user=3i 1465839830100400200",
+			want: []Point{{
+				Measurement: "cpu",
+				Tags:        map[string]string{"host": "server01", "region": "us-west"},
+				Fields: []Field{
+					{Key: "usage_idle", Value: 92.5, Type: FloatField},
+					{Key: "usage_user", Value: 3, Type: IntegerField},
+				},
+				Timestamp: time.Unix(0, 1465839830100400200),
+			}},
+		},
+		{
+			name:      "Point without tags with precision",
+			data:      "mem used=10u,active=t,status=\"ok, fine\" 1465839830",
+			precision: PrecisionSeconds,
+			want: []Point{{
+				Measurement: "mem",
+				Fields: []Field{
+					{Key: "used", Value: 10, Type: IntegerField},
+					{Key: "active", Value: 1, Type: BooleanField},
+					{Key: "status", Type: StringField},
+				},
+				Timestamp: time.Unix(1465839830, 0),
+			}},
+		},
+		{
+			name: "Escaped characters",
+			data: "disk\\ io,path=/var\\,log,dev\\=name=sda read\\ bytes=1",
+			want: []Point{{
+				Measurement: "disk io",
+				Tags:        map[string]string{"path": "/var,log", "dev=name": "sda"},
+				Fields:      []Field{{Key: "read bytes", Value: 1, Type: FloatField}},
+			}},
+		},
+		{
+			name: "Comments and empty lines",
+			data: "# comment\n\nload value=1\nload value=2\n",
+			want: []Point{
+				{Measurement: "load", Fields: []Field{{Key: "value", Value: 1, Type: FloatField}}},
+				{Measurement: "load", Fields: []Field{{Key: "value", Value: 2, Type: FloatField}}},
+			},
+		},
+		{name: "Missing fields", data: "cpu,host=a", wantErr: true},
+		{name: "Invalid field value", data: "cpu value=abc", wantErr: true},
+		{name: "NaN field value", data: "cpu value=NaN", wantErr: true},
+		{name: "Infinite field value", data: "cpu value=-inf", wantErr: true},
+		{name: "Invalid tag", data: "cpu,host value=1", wantErr: true},
+		{name: "Invalid timestamp", data: "cpu value=1 now", wantErr: true},
+		{name: "Unknown precision", data: "cpu value=1", precision: "h", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			points, err := Parse([]byte(tt.data), tt.precision)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, points)
+		})
+	}
+}
@@ -1,121 +1,107 @@
 package metrics
 
 import (
+	"context"
 	"fmt"
-	"math/rand"
-	"runtime"
 	"sync"
 
 	"github.com/rs/zerolog/log"
-	"github.com/shirou/gopsutil/v3/cpu"
-	"github.com/shirou/gopsutil/v3/mem"
 )
 
-// Collector собирает различные рантайм-метрики для их последующей отправки на сервер по протоколу HTTP.
-// В качестве источника метрик используются пакеты runtime и gopsutil.
+// maxCollectFailures число ошибок опроса источника подряд, после которого
+// полученные от него ранее значения метрик перестают публиковаться
+const maxCollectFailures = 3
+
+// Collector хранит последние значения метрик, полученные от зарегистрированных источников.
+// Опрос источников с их периодичностью выполняет агент, вызывая Update.
 type Collector struct {
-	RuntimeMetrics     map[string]float64
-	UtilizationMetrics map[string]float64
-	PollCount          int64
-	muRuntime          sync.RWMutex
-	muUtilize          sync.RWMutex
+	values   map[string][]*Metric
+	failures map[string]int
+	sources  []Source
+	mu       sync.RWMutex
 }
 
-// NewCollector создает экземпляр Collector
-func NewCollector() *Collector {
-	return &Collector{
-		PollCount:      0,
-		RuntimeMetrics: make(map[string]float64),
-
-		UtilizationMetrics: make(map[string]float64),
+// NewCollector создает экземпляр Collector и регистрирует в нем источники sources
+func NewCollector(sources ...Source) (*Collector, error) {
+	c := &Collector{
+		values:   make(map[string][]*Metric),
+		failures: make(map[string]int),
 	}
+	for _, source := range sources {
+		if err := c.Register(source); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
 }
 
-// UpdateRuntimeMetrics обновляет значения метрик RuntimeMetrics, используя пакет runtime,
-// а также счетчика PollCount и метрики RandomValue.
-func (c *Collector) UpdateRuntimeMetrics() {
-	c.muRuntime.Lock()
-	defer c.muRuntime.Unlock()
-
-	c.PollCount += 1
+// Register регистрирует источник метрик. Имена источников должны быть уникальны.
+func (c *Collector) Register(source Source) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	memStats := &runtime.MemStats{}
-	runtime.ReadMemStats(memStats)
-	c.RuntimeMetrics = map[string]float64{
-		"RandomValue":   rand.Float64(),
-		"GCCPUFraction": memStats.GCCPUFraction,
-		"Alloc":         float64(memStats.Alloc),
-		"BuckHashSys":   float64(memStats.BuckHashSys),
-		"Frees":         float64(memStats.Frees),
-		"GCSys":         float64(memStats.GCSys),
-		"HeapAlloc":     float64(memStats.HeapAlloc),
-		"HeapIdle":      float64(memStats.HeapIdle),
-		"HeapInuse":     float64(memStats.HeapInuse),
-		"HeapObjects":   float64(memStats.HeapObjects),
-		"HeapReleased":  float64(memStats.HeapReleased),
-		"HeapSys":       float64(memStats.HeapSys),
-		"LastGC":        float64(memStats.LastGC),
-		"Lookups":       float64(memStats.Lookups),
-		"MCacheInuse":   float64(memStats.MCacheInuse),
-		"MCacheSys":     float64(memStats.MCacheSys),
-		"MSpanInuse":    float64(memStats.MSpanInuse),
-		"MSpanSys":      float64(memStats.MSpanSys),
-		"Mallocs":       float64(memStats.Mallocs),
-		"NextGC":        float64(memStats.NextGC),
-		"NumForcedGC":   float64(memStats.NumForcedGC),
-		"NumGC":         float64(memStats.NumGC),
-		"OtherSys":      float64(memStats.OtherSys),
-		"PauseTotalNs":  float64(memStats.PauseTotalNs),
-		"StackInuse":    float64(memStats.StackInuse),
-		"StackSys":      float64(memStats.StackSys),
-		"Sys":           float64(memStats.Sys),
-		"TotalAlloc":    float64(memStats.TotalAlloc),
+	for _, registered := range c.sources {
+		if registered.Name() == source.Name() {
+			return fmt.Errorf("source %s is already registered", source.Name())
+		}
 	}
+	c.sources = append(c.sources, source)
+	return nil
 }
 
-// UpdateUtilizationMetrics обновляет значения метрик UtilizationMetrics, используя пакет gopsutil,
-func (c *Collector) UpdateUtilizationMetrics() {
-	c.muUtilize.Lock()
-	defer c.muUtilize.Unlock()
+// Sources возвращает зарегистрированные источники в порядке регистрации
+func (c *Collector) Sources() []Source {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
 
-	v, err := mem.VirtualMemory()
-	if err != nil {
-		log.Warn().Err(err).Msg("Failed to get memory stats")
-		return
-	}
+	sources := make([]Source, len(c.sources))
+	copy(sources, c.sources)
+	return sources
+}
 
-	c.UtilizationMetrics = map[string]float64{
-		"TotalMemory": float64(v.Total),
-		"FreeMemory":  float64(v.Free),
-	}
+// Update опрашивает источник source и сохраняет полученные значения метрик.
+// При ошибке опроса сохраняются значения, полученные ранее, но после
+// maxCollectFailures ошибок подряд они удаляются, чтобы не выдавать устаревшие
+// значения за актуальные.
+func (c *Collector) Update(ctx context.Context, source Source) {
+	collection, err := source.Collect(ctx)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	usage, err := cpu.Percent(0, true)
+	name := source.Name()
 	if err != nil {
-		log.Warn().Err(err).Msg("Failed to get cpu stats")
+		log.Warn().Err(err).Msgf("Failed to collect %s metrics", name)
+		c.failures[name]++
+		if c.failures[name] >= maxCollectFailures {
+			delete(c.values, name)
+		}
 		return
 	}
-	for i, value := range usage {
-		id := fmt.Sprintf("CPUutilization%d", i)
-		c.UtilizationMetrics[id] = value
-	}
+	c.failures[name] = 0
+	c.values[name] = collection
 }
 
-// ListMetrics возвращает список всех собранных значений метрик.
+// ListMetrics возвращает копии последних значений метрик всех источников.
+// Для счетчиков возвращаются накопленные значения, приращения вычисляет DeltaTracker.
 func (c *Collector) ListMetrics() []*Metric {
-	metrics := make([]*Metric, 0)
+	return c.ListSourceMetrics(nil)
+}
 
-	c.muRuntime.RLock()
-	metrics = append(metrics, NewCounter("PollCount", c.PollCount))
-	for id, value := range c.RuntimeMetrics {
-		metrics = append(metrics, NewGauge(id, value))
-	}
-	c.muRuntime.RUnlock()
+// ListSourceMetrics возвращает копии последних значений метрик источников,
+// для которых filter возвращает true, или всех источников, если filter равен nil
+func (c *Collector) ListSourceMetrics(filter func(Source) bool) []*Metric {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
 
-	c.muUtilize.RLock()
-	for id, value := range c.UtilizationMetrics {
-		metrics = append(metrics, NewGauge(id, value))
+	metrics := make([]*Metric, 0)
+	for _, source := range c.sources {
+		if filter != nil && !filter(source) {
+			continue
+		}
+		for _, metric := range c.values[source.Name()] {
+			metrics = append(metrics, metric.Copy())
+		}
 	}
-	c.muUtilize.RUnlock()
-
 	return metrics
 }
@@ -0,0 +1,67 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testSource struct {
+	err        error
+	name       string
+	collection []*Metric
+}
+
+func (s *testSource) Name() string {
+	return s.name
+}
+
+func (s *testSource) Interval() time.Duration {
+	return time.Second
+}
+
+func (s *testSource) Collect(_ context.Context) ([]*Metric, error) {
+	return s.collection, s.err
+}
+
+func TestCollector(t *testing.T) {
+	first := &testSource{name: "first", collection: []*Metric{NewCounter("PollCount", 1)}}
+	second := &testSource{name: "second", collection: []*Metric{NewGauge("Alloc", 1.5)}}
+
+	collector, err := NewCollector(first, second)
+	require.NoError(t, err)
+	require.Error(t, collector.Register(&testSource{name: "first"}))
+	assert.Equal(t, []Source{first, second}, collector.Sources())
+	assert.Empty(t, collector.ListMetrics())
+
+	ctx := context.Background()
+	collector.Update(ctx, first)
+	collector.Update(ctx, second)
+	assert.Equal(t, []*Metric{NewCounter("PollCount", 1), NewGauge("Alloc", 1.5)}, collector.ListMetrics())
+	assert.Equal(t, []*Metric{NewGauge("Alloc", 1.5)}, collector.ListSourceMetrics(func(source Source) bool {
+		return source.Name() == "second"
+	}))
+
+	// изменение полученных метрик не затрагивает сохраненные значения
+	*collector.ListMetrics()[0].Delta = 10
+	assert.Equal(t, int64(1), *collector.ListMetrics()[0].Delta)
+
+	// при ошибке опроса сохраняются предыдущие значения
+	second.collection, second.err = nil, errors.New("unavailable")
+	collector.Update(ctx, second)
+	assert.Equal(t, []*Metric{NewCounter("PollCount", 1), NewGauge("Alloc", 1.5)}, collector.ListMetrics())
+
+	// после maxCollectFailures ошибок подряд значения источника удаляются
+	for i := 1; i < maxCollectFailures; i++ {
+		collector.Update(ctx, second)
+	}
+	assert.Equal(t, []*Metric{NewCounter("PollCount", 1)}, collector.ListMetrics())
+
+	second.collection, second.err = []*Metric{NewGauge("Alloc", 2.5)}, nil
+	collector.Update(ctx, second)
+	assert.Equal(t, []*Metric{NewCounter("PollCount", 1), NewGauge("Alloc", 2.5)}, collector.ListMetrics())
+}
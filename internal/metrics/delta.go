@@ -0,0 +1,144 @@
+package metrics
+
+import "sync"
+
+// DeltaTracker преобразует накопленные значения счетчиков и гистограмм
+// в приращения с момента предыдущего вызова Delta.
+//
+// Сервер складывает полученные приращения, поэтому каждое приращение
+// должно быть передано на сервер ровно один раз: набор метрик, возвращенный Delta,
+// не следует терять или дублировать при повторных попытках отправки.
+type DeltaTracker struct {
+	counters   map[string]int64
+	histograms map[string]*Histogram
+	mu         sync.Mutex
+	skipFirst  bool
+}
+
+// NewDeltaTracker создает экземпляр DeltaTracker
+func NewDeltaTracker() *DeltaTracker {
+	return &DeltaTracker{
+		counters:   make(map[string]int64),
+		histograms: make(map[string]*Histogram),
+	}
+}
+
+// NewCumulativeTracker создает DeltaTracker для накопленных значений, которые сервер
+// получает от внешних источников. Такой трекер не знает, какая часть накопленного
+// значения уже учтена в хранилище, например до перезапуска сервера, поэтому первое
+// значение каждой серии только запоминается и не становится приращением.
+func NewCumulativeTracker() *DeltaTracker {
+	tracker := NewDeltaTracker()
+	tracker.skipFirst = true
+	return tracker
+}
+
+// Delta заменяет накопленные значения счетчиков и гистограмм в наборе collection
+// приращениями с момента предыдущего вызова. Уменьшение накопленного значения
+// считается сбросом счетчика, и приращением становится само накопленное значение.
+// Значения метрик типа GaugeType не изменяются.
+func (t *DeltaTracker) Delta(collection []*Metric) []*Metric {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	collection, counters, histograms := t.delta(collection)
+	t.commit(counters, histograms)
+	return collection
+}
+
+// Apply вычисляет приращения так же, как Delta, и передает полученный набор в put.
+// Состояние трекера обновляется, только если put завершился без ошибки, поэтому
+// после ошибки те же накопленные значения дают те же приращения.
+// Вызовы Apply выполняются последовательно, чтобы приращение не было учтено дважды.
+func (t *DeltaTracker) Apply(collection []*Metric, put func([]*Metric) error) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	collection, counters, histograms := t.delta(collection)
+	if err := put(collection); err != nil {
+		return err
+	}
+	t.commit(counters, histograms)
+	return nil
+}
+
+// delta заменяет накопленные значения приращениями, не изменяя состояние трекера,
+// и возвращает новые накопленные значения серий.
+// Если задан skipFirst, метрики серий, которые встретились впервые, исключаются из набора.
+func (t *DeltaTracker) delta(collection []*Metric) ([]*Metric, map[string]int64, map[string]*Histogram) {
+	result := make([]*Metric, 0, len(collection))
+	counters := make(map[string]int64)
+	histograms := make(map[string]*Histogram)
+	for _, metric := range collection {
+		key := metric.SeriesKey()
+		switch metric.MType {
+		case CounterType:
+			if metric.Delta == nil {
+				break
+			}
+			total := *metric.Delta
+			prev, ok := counters[key]
+			if !ok {
+				prev, ok = t.counters[key]
+			}
+			counters[key] = total
+			if !ok && t.skipFirst {
+				continue
+			}
+			if ok && prev <= total {
+				*metric.Delta = total - prev
+			}
+		case HistogramType:
+			if metric.Histogram == nil {
+				break
+			}
+			total := metric.Histogram.Copy()
+			prev, ok := histograms[key]
+			if !ok {
+				prev, ok = t.histograms[key]
+			}
+			histograms[key] = total
+			if !ok && t.skipFirst {
+				continue
+			}
+			if ok {
+				metric.Histogram = histogramDelta(total, prev)
+			}
+		}
+		result = append(result, metric)
+	}
+	return result, counters, histograms
+}
+
+// commit сохраняет накопленные значения серий
+func (t *DeltaTracker) commit(counters map[string]int64, histograms map[string]*Histogram) {
+	for key, total := range counters {
+		t.counters[key] = total
+	}
+	for key, total := range histograms {
+		t.histograms[key] = total
+	}
+}
+
+// histogramDelta возвращает приращение гистограммы total относительно prev
+// или копию total, если гистограмма была сброшена или изменились границы интервалов
+func histogramDelta(total, prev *Histogram) *Histogram {
+	delta := total.Copy()
+	if len(total.Counts) != len(prev.Counts) || total.Count < prev.Count {
+		return delta
+	}
+	for i := range total.Bounds {
+		if total.Bounds[i] != prev.Bounds[i] {
+			return delta
+		}
+	}
+	for i := range total.Counts {
+		if total.Counts[i] < prev.Counts[i] {
+			return total.Copy()
+		}
+		delta.Counts[i] -= prev.Counts[i]
+	}
+	delta.Sum -= prev.Sum
+	delta.Count -= prev.Count
+	return delta
+}
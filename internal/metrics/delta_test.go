@@ -0,0 +1,147 @@
+package metrics
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeltaTrackerCounter(t *testing.T) {
+	tests := []struct {
+		name  string
+		total int64
+		want  int64
+	}{
+		{name: "First value", total: 5, want: 5},
+		{name: "Increment", total: 8, want: 3},
+		{name: "No changes", total: 8, want: 0},
+		{name: "Reset", total: 2, want: 2},
+		{name: "Increment after reset", total: 6, want: 4},
+	}
+
+	tracker := NewDeltaTracker()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			collection := tracker.Delta([]*Metric{
+				NewCounter("PollCount", tt.total),
+				NewGauge("Alloc", 1.5),
+			})
+			require.Len(t, collection, 2)
+			assert.Equal(t, tt.want, *collection[0].Delta)
+			assert.Equal(t, 1.5, *collection[1].Value)
+		})
+	}
+}
+
+func TestDeltaTrackerLabels(t *testing.T) {
+	tracker := NewDeltaTracker()
+
+	first := NewCounter("Requests", 10)
+	first.Labels = map[string]string{"host": "a"}
+	second := NewCounter("Requests", 4)
+	second.Labels = map[string]string{"host": "b"}
+	tracker.Delta([]*Metric{first, second})
+
+	first = NewCounter("Requests", 12)
+	first.Labels = map[string]string{"host": "a"}
+	second = NewCounter("Requests", 7)
+	second.Labels = map[string]string{"host": "b"}
+	tracker.Delta([]*Metric{first, second})
+
+	assert.Equal(t, int64(2), *first.Delta)
+	assert.Equal(t, int64(3), *second.Delta)
+}
+
+func histogramMetric(m *Metric) *Metric {
+	return &Metric{
+		ID:        m.ID,
+		MType:     m.MType,
+		Histogram: m.Histogram.Copy(),
+	}
+}
+
+func TestDeltaTrackerHistogram(t *testing.T) {
+	tracker := NewDeltaTracker()
+
+	total := NewHistogram("Latency", []float64{1, 2})
+	total.Histogram.Observe(0.5)
+	total.Histogram.Observe(1.5)
+	tracker.Delta([]*Metric{histogramMetric(total)})
+
+	total.Histogram.Observe(3)
+	collection := tracker.Delta([]*Metric{histogramMetric(total)})
+	assert.Equal(t, &Histogram{
+		Bounds: []float64{1, 2},
+		Counts: []uint64{0, 0, 1},
+		Sum:    3,
+		Count:  1,
+	}, collection[0].Histogram)
+
+	reset := NewHistogram("Latency", []float64{1, 2})
+	reset.Histogram.Observe(0.5)
+	collection = tracker.Delta([]*Metric{histogramMetric(reset)})
+	assert.Equal(t, reset.Histogram, collection[0].Histogram)
+}
+
+func TestCumulativeTrackerApply(t *testing.T) {
+	tracker := NewCumulativeTracker()
+	errPut := errors.New("put failed")
+
+	tests := []struct {
+		err   error
+		name  string
+		want  []*Metric
+		total int64
+	}{
+		{
+			name:  "First value",
+			total: 100,
+			want:  []*Metric{NewGauge("Alloc", 1.5)},
+		},
+		{
+			name:  "Failed put",
+			total: 130,
+			err:   errPut,
+			want:  []*Metric{NewCounter("PollCount", 30), NewGauge("Alloc", 1.5)},
+		},
+		{
+			name:  "Retry",
+			total: 130,
+			want:  []*Metric{NewCounter("PollCount", 30), NewGauge("Alloc", 1.5)},
+		},
+		{
+			name:  "Increment",
+			total: 135,
+			want:  []*Metric{NewCounter("PollCount", 5), NewGauge("Alloc", 1.5)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []*Metric
+			err := tracker.Apply([]*Metric{
+				NewCounter("PollCount", tt.total),
+				NewGauge("Alloc", 1.5),
+			}, func(collection []*Metric) error {
+				got = collection
+				return tt.err
+			})
+			assert.ErrorIs(t, err, tt.err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestCumulativeTrackerRestart(t *testing.T) {
+	tracker := NewCumulativeTracker()
+	assert.Empty(t, tracker.Delta([]*Metric{NewCounter("NetworkBytesSent", 1000)}))
+	assert.Equal(t, []*Metric{NewCounter("NetworkBytesSent", 200)},
+		tracker.Delta([]*Metric{NewCounter("NetworkBytesSent", 1200)}))
+
+	// после перезапуска накопленное значение не становится приращением
+	tracker = NewCumulativeTracker()
+	assert.Empty(t, tracker.Delta([]*Metric{NewCounter("NetworkBytesSent", 1500)}))
+	assert.Equal(t, []*Metric{NewCounter("NetworkBytesSent", 50)},
+		tracker.Delta([]*Metric{NewCounter("NetworkBytesSent", 1550)}))
+}
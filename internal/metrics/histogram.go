@@ -0,0 +1,98 @@
+package metrics
+
+import (
+	"errors"
+	"math"
+	"sort"
+)
+
+// ErrHistogramBounds ошибка сложения гистограмм с различающимися границами интервалов
+var ErrHistogramBounds = errors.New("histogram bounds mismatch")
+
+// DefaultBuckets границы интервалов гистограммы по умолчанию,
+// подходящие для измерения времени выполнения запросов в секундах
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Histogram содержит распределение наблюдаемых значений по интервалам.
+// Элемент Counts[i] содержит количество значений из интервала (Bounds[i-1], Bounds[i]],
+// последний элемент Counts - количество значений, превышающих все границы Bounds.
+type Histogram struct {
+	Bounds []float64 `json:"bounds"`
+	Counts []uint64  `json:"counts"`
+	Sum    float64   `json:"sum"`
+	Count  uint64    `json:"count"`
+}
+
+// NewHistogram создает метрику типа HistogramType с пустой гистограммой
+// и заданными границами интервалов
+func NewHistogram(id string, bounds []float64) *Metric {
+	return &Metric{
+		ID:    id,
+		MType: HistogramType,
+		Histogram: &Histogram{
+			Bounds: append([]float64(nil), bounds...),
+			Counts: make([]uint64, len(bounds)+1),
+		},
+	}
+}
+
+// Observe добавляет в гистограмму наблюдаемое значение
+func (h *Histogram) Observe(value float64) {
+	h.ObserveN(value, 1)
+}
+
+// ObserveN добавляет в гистограмму n наблюдений значения value
+func (h *Histogram) ObserveN(value float64, n uint64) {
+	i := sort.SearchFloat64s(h.Bounds, value)
+	h.Counts[i] += n
+	h.Sum += value * float64(n)
+	h.Count += n
+}
+
+// Add добавляет к гистограмме значения другой гистограммы с теми же границами интервалов
+func (h *Histogram) Add(other *Histogram) error {
+	if len(h.Bounds) != len(other.Bounds) {
+		return ErrHistogramBounds
+	}
+	for i := range h.Bounds {
+		if h.Bounds[i] != other.Bounds[i] {
+			return ErrHistogramBounds
+		}
+	}
+
+	for i := range h.Counts {
+		h.Counts[i] += other.Counts[i]
+	}
+	h.Sum += other.Sum
+	h.Count += other.Count
+	return nil
+}
+
+// Valid проверяет, что границы интервалов возрастают, сумма значений конечна,
+// а количество значений согласовано с распределением по интервалам
+func (h *Histogram) Valid() bool {
+	if h == nil || len(h.Counts) != len(h.Bounds)+1 || math.IsNaN(h.Sum) || math.IsInf(h.Sum, 0) {
+		return false
+	}
+	for i := 1; i < len(h.Bounds); i++ {
+		if h.Bounds[i-1] >= h.Bounds[i] {
+			return false
+		}
+	}
+
+	var count uint64
+	for _, c := range h.Counts {
+		count += c
+	}
+	return count == h.Count
+}
+
+// Copy возвращает копию гистограммы
+func (h *Histogram) Copy() *Histogram {
+	return &Histogram{
+		Bounds: append([]float64(nil), h.Bounds...),
+		Counts: append([]uint64(nil), h.Counts...),
+		Sum:    h.Sum,
+		Count:  h.Count,
+	}
+}
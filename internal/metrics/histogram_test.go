@@ -0,0 +1,63 @@
+package metrics
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistogramObserve(t *testing.T) {
+	metric := NewHistogram("Latency", []float64{0.1, 1})
+	for _, value := range []float64{0.05, 0.1, 0.5, 5} {
+		metric.Histogram.Observe(value)
+	}
+
+	require.Equal(t, []uint64{2, 1, 1}, metric.Histogram.Counts)
+	require.Equal(t, uint64(4), metric.Histogram.Count)
+	require.InDelta(t, 5.65, metric.Histogram.Sum, 1e-9)
+	require.True(t, metric.Histogram.Valid())
+}
+
+func TestHistogramObserveN(t *testing.T) {
+	metric := NewHistogram("Latency", []float64{0.1, 1})
+	metric.Histogram.ObserveN(0.5, 3)
+	metric.Histogram.ObserveN(5, 0)
+
+	require.Equal(t, []uint64{0, 3, 0}, metric.Histogram.Counts)
+	require.Equal(t, uint64(3), metric.Histogram.Count)
+	require.InDelta(t, 1.5, metric.Histogram.Sum, 1e-9)
+	require.True(t, metric.Histogram.Valid())
+}
+
+func TestHistogramAdd(t *testing.T) {
+	h := &Histogram{Bounds: []float64{0.1, 1}, Counts: []uint64{1, 0, 2}, Sum: 10, Count: 3}
+	other := &Histogram{Bounds: []float64{0.1, 1}, Counts: []uint64{0, 1, 0}, Sum: 0.5, Count: 1}
+
+	require.NoError(t, h.Add(other))
+	require.Equal(t, &Histogram{Bounds: []float64{0.1, 1}, Counts: []uint64{1, 1, 2}, Sum: 10.5, Count: 4}, h)
+
+	require.ErrorIs(t, h.Add(&Histogram{Bounds: []float64{1}, Counts: []uint64{0, 0}}), ErrHistogramBounds)
+	require.ErrorIs(t, h.Add(&Histogram{Bounds: []float64{0.1, 2}, Counts: []uint64{0, 0, 0}}), ErrHistogramBounds)
+}
+
+func TestHistogramValid(t *testing.T) {
+	tests := []struct {
+		histogram *Histogram
+		name      string
+		valid     bool
+	}{
+		{name: "Nil", histogram: nil, valid: false},
+		{name: "Empty", histogram: &Histogram{Counts: []uint64{0}}, valid: true},
+		{name: "Counts length", histogram: &Histogram{Bounds: []float64{1}, Counts: []uint64{0}}, valid: false},
+		{name: "Unordered bounds", histogram: &Histogram{Bounds: []float64{1, 0.1}, Counts: []uint64{0, 0, 0}}, valid: false},
+		{name: "Count mismatch", histogram: &Histogram{Bounds: []float64{1}, Counts: []uint64{1, 1}, Count: 1}, valid: false},
+		{name: "Infinite sum", histogram: &Histogram{Counts: []uint64{1}, Sum: math.Inf(1), Count: 1}, valid: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.valid, tt.histogram.Valid())
+		})
+	}
+}
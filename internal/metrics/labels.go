@@ -0,0 +1,134 @@
+package metrics
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ValidateLabels проверяет, что имена меток состоят из латинских букв, цифр и символа '_'
+// и не начинаются с цифры
+func ValidateLabels(labels map[string]string) bool {
+	for name := range labels {
+		if !validLabelName(name) {
+			return false
+		}
+	}
+	return true
+}
+
+func validLabelName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r == '_':
+		case r >= '0' && r <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// EncodeLabels возвращает каноническое представление набора меток вида {k1="v1",k2="v2"},
+// метки упорядочены по имени. Для пустого набора возвращается пустая строка.
+func EncodeLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, name := range names {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(name)
+		b.WriteByte('=')
+		b.WriteString(strconv.Quote(labels[name]))
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// DecodeLabels разбирает представление набора меток, полученное с помощью EncodeLabels
+func DecodeLabels(s string) (map[string]string, error) {
+	if s == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(s, "{") || !strings.HasSuffix(s, "}") {
+		return nil, fmt.Errorf("invalid labels: %s", s)
+	}
+
+	labels := make(map[string]string)
+	rest := s[1 : len(s)-1]
+	for rest != "" {
+		eq := strings.IndexByte(rest, '=')
+		if eq <= 0 {
+			return nil, fmt.Errorf("invalid labels: %s", s)
+		}
+		name := rest[:eq]
+
+		quoted, err := strconv.QuotedPrefix(rest[eq+1:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid labels: %s", s)
+		}
+		value, err := strconv.Unquote(quoted)
+		if err != nil {
+			return nil, fmt.Errorf("invalid labels: %s", s)
+		}
+		labels[name] = value
+
+		rest = strings.TrimPrefix(rest[eq+1+len(quoted):], ",")
+	}
+	return labels, nil
+}
+
+// MergeLabels возвращает объединение наборов меток.
+// При совпадении имен приоритет имеют метки из более позднего набора.
+func MergeLabels(sets ...map[string]string) map[string]string {
+	var result map[string]string
+	for _, labels := range sets {
+		for name, value := range labels {
+			if result == nil {
+				result = make(map[string]string)
+			}
+			result[name] = value
+		}
+	}
+	return result
+}
+
+// ValidateID проверяет, что имя метрики не содержит символ '{', с которого
+// в ключе временного ряда начинается набор меток
+func ValidateID(id string) bool {
+	return !strings.Contains(id, "{")
+}
+
+// SeriesKey возвращает ключ временного ряда метрики, состоящий из ее имени и набора меток
+func (m *Metric) SeriesKey() string {
+	return m.ID + EncodeLabels(m.Labels)
+}
+
+// ParseSeriesKey разбирает ключ временного ряда, полученный с помощью SeriesKey
+func ParseSeriesKey(key string) (string, map[string]string, error) {
+	i := strings.IndexByte(key, '{')
+	if i < 0 {
+		return key, nil, nil
+	}
+
+	labels, err := DecodeLabels(key[i:])
+	if err != nil {
+		return "", nil, err
+	}
+	return key[:i], labels, nil
+}
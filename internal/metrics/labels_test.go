@@ -0,0 +1,69 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeDecodeLabels(t *testing.T) {
+	tests := []struct {
+		labels  map[string]string
+		name    string
+		encoded string
+	}{
+		{
+			name:    "Empty",
+			labels:  nil,
+			encoded: "",
+		},
+		{
+			name:    "Sorted by name",
+			labels:  map[string]string{"host": "localhost", "agent_id": "1"},
+			encoded: `{agent_id="1",host="localhost"}`,
+		},
+		{
+			name:    "Escaped value",
+			labels:  map[string]string{"path": `C:\\"dir",a=b`},
+			encoded: `{path="C:\\\\\"dir\",a=b"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded := EncodeLabels(tt.labels)
+			require.Equal(t, tt.encoded, encoded)
+
+			decoded, err := DecodeLabels(encoded)
+			require.NoError(t, err)
+			require.Equal(t, tt.labels, decoded)
+		})
+	}
+}
+
+func TestParseSeriesKey(t *testing.T) {
+	metric := NewGauge("Alloc", 1.0)
+	metric.Labels = map[string]string{"host": "localhost"}
+
+	id, labels, err := ParseSeriesKey(metric.SeriesKey())
+	require.NoError(t, err)
+	require.Equal(t, "Alloc", id)
+	require.Equal(t, metric.Labels, labels)
+
+	_, _, err = ParseSeriesKey(`Alloc{host=localhost}`)
+	require.Error(t, err)
+}
+
+func TestValidateLabels(t *testing.T) {
+	require.True(t, ValidateLabels(nil))
+	require.True(t, ValidateLabels(map[string]string{"agent_id": "", "_host1": "a"}))
+	require.False(t, ValidateLabels(map[string]string{"1host": "a"}))
+	require.False(t, ValidateLabels(map[string]string{"host-name": "a"}))
+	require.False(t, ValidateLabels(map[string]string{"": "a"}))
+}
+
+func TestValidateID(t *testing.T) {
+	require.True(t, ValidateID("http.requests:total"))
+	require.False(t, ValidateID(`Alloc{host="a"}`))
+	require.False(t, ValidateID("cpu{"))
+}
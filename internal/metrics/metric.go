@@ -7,17 +7,20 @@ import (
 
 // Возможные типы метрик
 const (
-	GaugeType   string = "gauge"
-	CounterType string = "counter"
+	GaugeType     string = "gauge"
+	CounterType   string = "counter"
+	HistogramType string = "histogram"
 )
 
 // Metric содержит информацию о метрике
 type Metric struct {
-	ID    string   `json:"id"`
-	MType string   `json:"type"`
-	Delta *int64   `json:"delta,omitempty"`
-	Value *float64 `json:"value,omitempty"`
-	Hash  string   `json:"hash,omitempty"`
+	ID        string            `json:"id"`
+	MType     string            `json:"type"`
+	Delta     *int64            `json:"delta,omitempty"`
+	Value     *float64          `json:"value,omitempty"`
+	Histogram *Histogram        `json:"histogram,omitempty"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	Hash      string            `json:"hash,omitempty"`
 }
 
 // NewGauge создает метрику типа GaugeType
@@ -37,3 +40,19 @@ func NewCounter(id string, delta int64) *Metric {
 		Delta: pointy.Int64(delta),
 	}
 }
+
+// Copy возвращает копию метрики, не разделяющую с ней значения и метки
+func (m *Metric) Copy() *Metric {
+	c := *m
+	if m.Delta != nil {
+		c.Delta = pointy.Int64(*m.Delta)
+	}
+	if m.Value != nil {
+		c.Value = pointy.Float64(*m.Value)
+	}
+	if m.Histogram != nil {
+		c.Histogram = m.Histogram.Copy()
+	}
+	c.Labels = MergeLabels(m.Labels)
+	return &c
+}
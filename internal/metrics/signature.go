@@ -5,8 +5,8 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
-	"hash"
 	"io"
+	"strings"
 )
 
 // Signer интерфейс, предоставляющий механизм подписи передаваемых метрик
@@ -18,9 +18,11 @@ type Signer interface {
 	Validate(metric *Metric) (bool, error)
 }
 
-// hmacSigner подписывает значения метрик с помощью алгоритма HMAC
+// hmacSigner подписывает значения метрик с помощью алгоритма HMAC.
+// Подпись вычисляется новым экземпляром hash.Hash, поэтому hmacSigner
+// можно использовать одновременно из нескольких горутин.
 type hmacSigner struct {
-	hash hash.Hash
+	key []byte
 }
 
 // NewHMACSigner возвращает объект hmacSigner
@@ -29,7 +31,7 @@ func NewHMACSigner(key string) *hmacSigner {
 		return nil
 	}
 	return &hmacSigner{
-		hash: hmac.New(sha256.New, []byte(key)),
+		key: []byte(key),
 	}
 }
 
@@ -70,16 +72,38 @@ func (s *hmacSigner) computeHash(metric *Metric) ([]byte, error) {
 	var msg string
 	switch metric.MType {
 	case CounterType:
+		if metric.Delta == nil {
+			return nil, fmt.Errorf("counter has no value")
+		}
 		msg = fmt.Sprintf("%s:%s:%d", metric.ID, metric.MType, *metric.Delta)
 	case GaugeType:
+		if metric.Value == nil {
+			return nil, fmt.Errorf("gauge has no value")
+		}
 		msg = fmt.Sprintf("%s:%s:%f", metric.ID, metric.MType, *metric.Value)
+	case HistogramType:
+		h := metric.Histogram
+		if h == nil {
+			return nil, fmt.Errorf("histogram has no value")
+		}
+		bounds := make([]string, 0, len(h.Bounds))
+		for _, bound := range h.Bounds {
+			bounds = append(bounds, fmt.Sprintf("%f", bound))
+		}
+		counts := make([]string, 0, len(h.Counts))
+		for _, count := range h.Counts {
+			counts = append(counts, fmt.Sprintf("%d", count))
+		}
+		msg = fmt.Sprintf("%s:%s:%s:%s:%f:%d", metric.ID, metric.MType,
+			strings.Join(bounds, ","), strings.Join(counts, ","), h.Sum, h.Count)
 	default:
 		return nil, fmt.Errorf("unknown metric type")
 	}
+	msg += EncodeLabels(metric.Labels)
 
-	s.hash.Reset()
-	if _, err := io.WriteString(s.hash, msg); err != nil {
+	h := hmac.New(sha256.New, s.key)
+	if _, err := io.WriteString(h, msg); err != nil {
 		return nil, err
 	}
-	return s.hash.Sum(nil), nil
+	return h.Sum(nil), nil
 }
@@ -0,0 +1,38 @@
+package metrics
+
+import (
+	"context"
+	"time"
+)
+
+// Source определяет интерфейс источника метрик агента
+type Source interface {
+	// Name возвращает уникальное имя источника
+	Name() string
+
+	// Interval возвращает период опроса источника
+	Interval() time.Duration
+
+	// Collect возвращает текущие значения метрик источника.
+	// Счетчики и гистограммы содержат накопленные значения.
+	Collect(ctx context.Context) ([]*Metric, error)
+}
+
+// AgentSource определяет необязательный интерфейс источника, накопленные значения
+// которого отсчитываются с момента запуска агента, как у счетчика опросов PollCount.
+// Накопленные значения остальных источников, например счетчики сетевых интерфейсов
+// с момента загрузки системы, включают события до запуска агента.
+type AgentSource interface {
+	Source
+
+	// StartsWithAgent возвращает true, если накопленные значения источника
+	// отсчитываются с момента запуска агента
+	StartsWithAgent() bool
+}
+
+// StartsWithAgent возвращает true, если накопленные значения источника source
+// отсчитываются с момента запуска агента
+func StartsWithAgent(source Source) bool {
+	s, ok := source.(AgentSource)
+	return ok && s.StartsWithAgent()
+}
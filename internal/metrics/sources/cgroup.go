@@ -0,0 +1,211 @@
+package sources
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hikjik/go-metrics/internal/metrics"
+)
+
+// selfCgroupFile содержит cgroup процесса агента
+const selfCgroupFile = "/proc/self/cgroup"
+
+// cgroupCPUCounters задает имена метрик для счетчиков файла cpu.stat
+var cgroupCPUCounters = map[string]string{
+	"usage_usec":     "CgroupCPUUsageUsec",
+	"user_usec":      "CgroupCPUUserUsec",
+	"system_usec":    "CgroupCPUSystemUsec",
+	"nr_periods":     "CgroupCPUPeriods",
+	"nr_throttled":   "CgroupCPUThrottledPeriods",
+	"throttled_usec": "CgroupCPUThrottledUsec",
+}
+
+// cgroupIOCounters задает имена метрик для счетчиков файла io.stat
+var cgroupIOCounters = map[string]string{
+	"rbytes": "CgroupIOReadBytes",
+	"wbytes": "CgroupIOWriteBytes",
+	"rios":   "CgroupIOReads",
+	"wios":   "CgroupIOWrites",
+}
+
+// Cgroup источник метрик потребления ресурсов cgroup v2.
+// Метрики каждой cgroup содержат метку cgroup с ее путем относительно корня иерархии,
+// счетчики io.stat - также метку device с номером устройства.
+// Файлы отключенных контроллеров пропускаются.
+//
+// Счетчики cpu.stat и io.stat накоплены с момента создания cgroup, а не запуска агента,
+// поэтому источник не реализует metrics.AgentSource: агент отправляет на сервер
+// только приращения после первого опроса.
+type Cgroup struct {
+	root     string
+	paths    []string
+	interval time.Duration
+}
+
+// NewCgroup создает источник Cgroup с периодом опроса interval для cgroup paths
+// в иерархии, смонтированной в root. Если paths не заданы, используется cgroup агента.
+func NewCgroup(interval time.Duration, root string, paths []string) *Cgroup {
+	return &Cgroup{
+		root:     root,
+		paths:    paths,
+		interval: interval,
+	}
+}
+
+func (c *Cgroup) Name() string {
+	return "cgroup"
+}
+
+func (c *Cgroup) Interval() time.Duration {
+	return c.interval
+}
+
+func (c *Cgroup) Collect(_ context.Context) ([]*metrics.Metric, error) {
+	paths := c.paths
+	if len(paths) == 0 {
+		data, err := os.ReadFile(selfCgroupFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read agent cgroup: %w", err)
+		}
+		path, err := parseSelfCgroup(data)
+		if err != nil {
+			return nil, err
+		}
+		paths = []string{path}
+	}
+
+	var collection []*metrics.Metric
+	for _, path := range paths {
+		m, err := c.collectCgroup(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to collect cgroup %s: %w", path, err)
+		}
+		collection = append(collection, m...)
+	}
+	return collection, nil
+}
+
+func (c *Cgroup) collectCgroup(path string) ([]*metrics.Metric, error) {
+	dir := filepath.Join(c.root, path)
+	if _, err := os.Stat(dir); err != nil {
+		return nil, err
+	}
+	labels := map[string]string{"cgroup": path}
+
+	var collection []*metrics.Metric
+	err := readCgroupFile(dir, "cpu.stat", func(data []byte) error {
+		for _, fields := range splitLines(data) {
+			if len(fields) != 2 || cgroupCPUCounters[fields[0]] == "" {
+				continue
+			}
+			value, err := strconv.ParseUint(fields[1], 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid cpu.stat value %s: %w", fields[1], err)
+			}
+			collection = append(collection, newCounter(cgroupCPUCounters[fields[0]], value, labels))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for file, id := range map[string]string{
+		"memory.current": "CgroupMemoryCurrent",
+		"memory.max":     "CgroupMemoryMax",
+		"pids.current":   "CgroupPidsCurrent",
+	} {
+		id := id
+		err = readCgroupFile(dir, file, func(data []byte) error {
+			value := strings.TrimSpace(string(data))
+			if value == "max" {
+				// лимит не установлен
+				return nil
+			}
+			v, parseErr := strconv.ParseUint(value, 10, 64)
+			if parseErr != nil {
+				return fmt.Errorf("invalid %s value %s: %w", file, value, parseErr)
+			}
+			collection = append(collection, newGauge(id, float64(v), labels))
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = readCgroupFile(dir, "io.stat", func(data []byte) error {
+		for _, fields := range splitLines(data) {
+			if len(fields) < 2 {
+				continue
+			}
+			deviceLabels := metrics.MergeLabels(labels, map[string]string{"device": fields[0]})
+			for _, field := range fields[1:] {
+				key, value := splitKeyValue(field)
+				if cgroupIOCounters[key] == "" {
+					continue
+				}
+				v, parseErr := strconv.ParseUint(value, 10, 64)
+				if parseErr != nil {
+					return fmt.Errorf("invalid io.stat value %s: %w", field, parseErr)
+				}
+				collection = append(collection, newCounter(cgroupIOCounters[key], v, deviceLabels))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return collection, nil
+}
+
+// readCgroupFile передает содержимое файла name каталога dir функции parse.
+// Отсутствующие файлы пропускаются.
+func readCgroupFile(dir, name string, parse func(data []byte) error) error {
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return parse(data)
+}
+
+// parseSelfCgroup возвращает путь cgroup v2 из содержимого файла /proc/self/cgroup
+func parseSelfCgroup(data []byte) (string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		if line := scanner.Text(); strings.HasPrefix(line, "0::") {
+			return strings.TrimPrefix(line, "0::"), nil
+		}
+	}
+	return "", errors.New("cgroup v2 is not used")
+}
+
+func splitLines(data []byte) [][]string {
+	var lines [][]string
+	for _, line := range strings.Split(string(data), "\n") {
+		if fields := strings.Fields(line); len(fields) > 0 {
+			lines = append(lines, fields)
+		}
+	}
+	return lines
+}
+
+func splitKeyValue(field string) (string, string) {
+	i := strings.IndexByte(field, '=')
+	if i < 0 {
+		return field, ""
+	}
+	return field[:i], field[i+1:]
+}
@@ -0,0 +1,66 @@
+package sources
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hikjik/go-metrics/internal/metrics"
+)
+
+func TestCgroup(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "system.slice", "app.service")
+	require.NoError(t, os.MkdirAll(dir, 0755))
+
+	files := map[string]string{
+		"cpu.stat":       "usage_usec 1500\nuser_usec 1000\nsystem_usec 500\ncore_sched.force_idle_usec 0\n",
+		"memory.current": "4096\n",
+		"memory.max":     "max\n",
+		"pids.current":   "7\n",
+		"io.stat":        "8:0 rbytes=1024 wbytes=2048 rios=3 wios=4 dbytes=0 dios=0\n",
+	}
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+
+	source := NewCgroup(time.Second, root, []string{"/system.slice/app.service"})
+	assert.False(t, metrics.StartsWithAgent(source))
+	collection, err := source.Collect(context.Background())
+	require.NoError(t, err)
+
+	labels := map[string]string{"cgroup": "/system.slice/app.service"}
+	deviceLabels := map[string]string{"cgroup": "/system.slice/app.service", "device": "8:0"}
+	assert.ElementsMatch(t, []*metrics.Metric{
+		newCounter("CgroupCPUUsageUsec", 1500, labels),
+		newCounter("CgroupCPUUserUsec", 1000, labels),
+		newCounter("CgroupCPUSystemUsec", 500, labels),
+		newGauge("CgroupMemoryCurrent", 4096, labels),
+		newGauge("CgroupPidsCurrent", 7, labels),
+		newCounter("CgroupIOReadBytes", 1024, deviceLabels),
+		newCounter("CgroupIOWriteBytes", 2048, deviceLabels),
+		newCounter("CgroupIOReads", 3, deviceLabels),
+		newCounter("CgroupIOWrites", 4, deviceLabels),
+	}, collection)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "memory.current"), []byte("invalid"), 0644))
+	_, err = source.Collect(context.Background())
+	assert.Error(t, err)
+
+	_, err = NewCgroup(time.Second, root, []string{"/missing"}).Collect(context.Background())
+	assert.Error(t, err)
+}
+
+func TestParseSelfCgroup(t *testing.T) {
+	path, err := parseSelfCgroup([]byte("0::/user.slice/session-1.scope\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "/user.slice/session-1.scope", path)
+
+	_, err = parseSelfCgroup([]byte("12:memory:/docker/abc\n"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,102 @@
+package sources
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/shirou/gopsutil/v3/disk"
+
+	"github.com/hikjik/go-metrics/internal/metrics"
+)
+
+// Disk источник счетчиков операций ввода-вывода дисков.
+// Метрики каждого устройства содержат метку device.
+type Disk struct {
+	interval time.Duration
+}
+
+// NewDisk создает источник Disk с периодом опроса interval
+func NewDisk(interval time.Duration) *Disk {
+	return &Disk{interval: interval}
+}
+
+func (d *Disk) Name() string {
+	return "disk"
+}
+
+func (d *Disk) Interval() time.Duration {
+	return d.interval
+}
+
+func (d *Disk) Collect(ctx context.Context) ([]*metrics.Metric, error) {
+	counters, err := disk.IOCountersWithContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get disk io counters: %w", err)
+	}
+
+	var collection []*metrics.Metric
+	for device, stat := range counters {
+		labels := map[string]string{"device": device}
+		collection = append(collection,
+			newCounter("DiskReads", stat.ReadCount, labels),
+			newCounter("DiskWrites", stat.WriteCount, labels),
+			newCounter("DiskReadBytes", stat.ReadBytes, labels),
+			newCounter("DiskWriteBytes", stat.WriteBytes, labels),
+			newCounter("DiskReadTimeMs", stat.ReadTime, labels),
+			newCounter("DiskWriteTimeMs", stat.WriteTime, labels),
+			newCounter("DiskIOTimeMs", stat.IoTime, labels),
+		)
+	}
+	return collection, nil
+}
+
+// Filesystem источник метрик использования файловых систем.
+// Метрики каждой точки монтирования содержат метки mountpoint и fstype.
+type Filesystem struct {
+	interval time.Duration
+}
+
+// NewFilesystem создает источник Filesystem с периодом опроса interval
+func NewFilesystem(interval time.Duration) *Filesystem {
+	return &Filesystem{interval: interval}
+}
+
+func (f *Filesystem) Name() string {
+	return "filesystem"
+}
+
+func (f *Filesystem) Interval() time.Duration {
+	return f.interval
+}
+
+func (f *Filesystem) Collect(ctx context.Context) ([]*metrics.Metric, error) {
+	partitions, err := disk.PartitionsWithContext(ctx, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get partitions: %w", err)
+	}
+
+	var collection []*metrics.Metric
+	for _, partition := range partitions {
+		usage, err := disk.UsageWithContext(ctx, partition.Mountpoint)
+		if err != nil {
+			log.Debug().Err(err).Msgf("Failed to get usage of %s", partition.Mountpoint)
+			continue
+		}
+		if usage.Total == 0 {
+			continue
+		}
+
+		labels := map[string]string{"mountpoint": partition.Mountpoint, "fstype": partition.Fstype}
+		collection = append(collection,
+			newGauge("FilesystemTotalBytes", float64(usage.Total), labels),
+			newGauge("FilesystemUsedBytes", float64(usage.Used), labels),
+			newGauge("FilesystemFreeBytes", float64(usage.Free), labels),
+			newGauge("FilesystemUsedPercent", usage.UsedPercent, labels),
+			newGauge("FilesystemInodesTotal", float64(usage.InodesTotal), labels),
+			newGauge("FilesystemInodesFree", float64(usage.InodesFree), labels),
+		)
+	}
+	return collection, nil
+}
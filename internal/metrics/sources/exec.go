@@ -0,0 +1,187 @@
+package sources
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/hikjik/go-metrics/internal/metrics"
+	"github.com/hikjik/go-metrics/internal/prometheus"
+)
+
+// Форматы вывода внешних команд
+const (
+	ExecFormatSimple     = "simple"
+	ExecFormatPrometheus = "prometheus"
+)
+
+// maxStderrSize максимальный размер вывода ошибок команды, добавляемого в сообщение об ошибке
+const maxStderrSize = 512
+
+// Exec источник метрик, получаемых из вывода внешней команды.
+//
+// В формате ExecFormatSimple каждая строка вывода имеет вид name type value [label=value ...],
+// где type - gauge или counter; пустые строки и строки, начинающиеся с '#', пропускаются.
+// В формате ExecFormatPrometheus вывод разбирается как текстовый формат Prometheus.
+// Счетчики должны содержать накопленные значения, приращения вычисляет агент.
+// Накопленные значения могут включать события до запуска агента, поэтому
+// первое значение счетчика после запуска агента не отправляется на сервер.
+//
+// Помимо метрик команды, возвращается метрика ExecSuccess с меткой exec,
+// равная 1 при успешном выполнении команды и 0 при ошибке или превышении таймаута.
+type Exec struct {
+	name     string
+	format   string
+	command  []string
+	timeout  time.Duration
+	interval time.Duration
+}
+
+// NewExec создает источник Exec с именем name, выполняющий команду command
+// с периодом interval и таймаутом timeout. Вывод команды разбирается в формате format.
+func NewExec(name string, command []string, format string, interval, timeout time.Duration) (*Exec, error) {
+	if name == "" {
+		return nil, errors.New("exec source name is empty")
+	}
+	if len(command) == 0 {
+		return nil, fmt.Errorf("exec source %s: command is empty", name)
+	}
+	switch format {
+	case "":
+		format = ExecFormatSimple
+	case ExecFormatSimple, ExecFormatPrometheus:
+	default:
+		return nil, fmt.Errorf("exec source %s: unknown format %s", name, format)
+	}
+	return &Exec{
+		name:     name,
+		format:   format,
+		command:  command,
+		timeout:  timeout,
+		interval: interval,
+	}, nil
+}
+
+func (e *Exec) Name() string {
+	return "exec_" + e.name
+}
+
+func (e *Exec) Interval() time.Duration {
+	return e.interval
+}
+
+func (e *Exec) Collect(ctx context.Context) ([]*metrics.Metric, error) {
+	labels := map[string]string{"exec": e.name}
+
+	collection, err := e.run(ctx)
+	if err != nil {
+		log.Warn().Err(err).Msgf("Failed to run exec source %s", e.name)
+		return []*metrics.Metric{newGauge("ExecSuccess", 0, labels)}, nil
+	}
+	return append(collection, newGauge("ExecSuccess", 1, labels)), nil
+}
+
+// run выполняет команду и разбирает ее вывод
+func (e *Exec) run(ctx context.Context) ([]*metrics.Metric, error) {
+	ctx, cancel := context.WithTimeout(ctx, e.timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(e.command[0], e.command[1:]...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	// команда запускается в отдельной группе процессов, чтобы по таймауту
+	// завершить и порожденные ею процессы, удерживающие ее вывод
+	setProcessGroup(cmd)
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start command: %w", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		if killErr := killProcessGroup(cmd); killErr != nil {
+			log.Warn().Err(killErr).Msgf("Failed to kill exec source %s", e.name)
+		}
+		<-done
+		return nil, fmt.Errorf("command timed out after %s", e.timeout)
+	}
+	if err != nil {
+		message := stderr.String()
+		if len(message) > maxStderrSize {
+			message = message[:maxStderrSize]
+		}
+		return nil, fmt.Errorf("command failed: %w: %s", err, strings.TrimSpace(message))
+	}
+
+	if e.format == ExecFormatPrometheus {
+		return prometheus.Parse(&stdout)
+	}
+	return parseSimple(&stdout)
+}
+
+// parseSimple разбирает строки вида name type value [label=value ...]
+func parseSimple(r io.Reader) ([]*metrics.Metric, error) {
+	var collection []*metrics.Metric
+
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			return nil, fmt.Errorf("line %d: expected name, type and value", n)
+		}
+
+		var labels map[string]string
+		for _, field := range fields[3:] {
+			name, value := splitKeyValue(field)
+			if name == "" || !strings.Contains(field, "=") {
+				return nil, fmt.Errorf("line %d: invalid label %s", n, field)
+			}
+			labels = metrics.MergeLabels(labels, map[string]string{name: value})
+		}
+
+		var m *metrics.Metric
+		switch fields[1] {
+		case metrics.GaugeType:
+			value, err := strconv.ParseFloat(fields[2], 64)
+			if err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+				return nil, fmt.Errorf("line %d: invalid gauge value %s", n, fields[2])
+			}
+			m = newGauge(fields[0], value, labels)
+		case metrics.CounterType:
+			value, err := strconv.ParseUint(fields[2], 10, 64)
+			if err != nil || value > math.MaxInt64 {
+				return nil, fmt.Errorf("line %d: invalid counter value %s", n, fields[2])
+			}
+			m = newCounter(fields[0], value, labels)
+		default:
+			return nil, fmt.Errorf("line %d: unknown metric type %s", n, fields[1])
+		}
+		collection = append(collection, m)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return collection, nil
+}
@@ -0,0 +1,171 @@
+package sources
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hikjik/go-metrics/internal/metrics"
+)
+
+func TestParseSimple(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		want    []*metrics.Metric
+		wantErr bool
+	}{
+		{
+			name: "Gauges and counters",
+			text: "# business checks\n" +
+				"QueueDepth gauge 42 queue=orders\n" +
+				"\n" +
+				"CertExpirySeconds gauge 86400.5\n" +
+				"ProcessedOrders counter 1000\n",
+			want: []*metrics.Metric{
+				newGauge("QueueDepth", 42, map[string]string{"queue": "orders"}),
+				newGauge("CertExpirySeconds", 86400.5, nil),
+				newCounter("ProcessedOrders", 1000, nil),
+			},
+		},
+		{
+			name:    "Missing value",
+			text:    "QueueDepth gauge\n",
+			wantErr: true,
+		},
+		{
+			name:    "Unknown type",
+			text:    "QueueDepth summary 1\n",
+			wantErr: true,
+		},
+		{
+			name:    "Negative counter",
+			text:    "ProcessedOrders counter -1\n",
+			wantErr: true,
+		},
+		{
+			name:    "NaN gauge",
+			text:    "QueueDepth gauge NaN\n",
+			wantErr: true,
+		},
+		{
+			name:    "Infinite gauge",
+			text:    "QueueDepth gauge +Inf\n",
+			wantErr: true,
+		},
+		{
+			name:    "Counter out of range",
+			text:    "ProcessedOrders counter 9223372036854775808\n",
+			wantErr: true,
+		},
+		{
+			name:    "Invalid label",
+			text:    "QueueDepth gauge 1 orders\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSimple(strings.NewReader(tt.text))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestExec(t *testing.T) {
+	success := map[string]string{"exec": "check"}
+	tests := []struct {
+		name    string
+		format  string
+		command []string
+		want    []*metrics.Metric
+		timeout time.Duration
+	}{
+		{
+			name:    "Simple format",
+			command: []string{"echo", "QueueDepth gauge 3"},
+			want: []*metrics.Metric{
+				newGauge("QueueDepth", 3, nil),
+				newGauge("ExecSuccess", 1, success),
+			},
+		},
+		{
+			name:    "Prometheus format",
+			command: []string{"printf", "# TYPE jobs_total counter\\njobs_total 7\\n"},
+			format:  ExecFormatPrometheus,
+			want: []*metrics.Metric{
+				newCounter("jobs", 7, nil),
+				newGauge("ExecSuccess", 1, success),
+			},
+		},
+		{
+			name:    "Command failed",
+			command: []string{"sh", "-c", "echo broken >&2; exit 1"},
+			want:    []*metrics.Metric{newGauge("ExecSuccess", 0, success)},
+		},
+		{
+			name:    "Invalid output",
+			command: []string{"echo", "QueueDepth"},
+			want:    []*metrics.Metric{newGauge("ExecSuccess", 0, success)},
+		},
+		{
+			name:    "Timeout",
+			command: []string{"sleep", "5"},
+			timeout: time.Millisecond * 50,
+			want:    []*metrics.Metric{newGauge("ExecSuccess", 0, success)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			timeout := tt.timeout
+			if timeout == 0 {
+				timeout = time.Second * 5
+			}
+			source, err := NewExec("check", tt.command, tt.format, time.Second, timeout)
+			require.NoError(t, err)
+			assert.Equal(t, "exec_check", source.Name())
+
+			got, err := source.Collect(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	_, err := NewExec("check", nil, "", time.Second, time.Second)
+	assert.Error(t, err)
+	_, err = NewExec("check", []string{"true"}, "xml", time.Second, time.Second)
+	assert.Error(t, err)
+}
+
+func TestExecTimeoutKillsProcessGroup(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("process groups are not supported")
+	}
+
+	marker := filepath.Join(t.TempDir(), "marker")
+	// порожденный процесс удерживает вывод команды и переживает ее завершение
+	command := []string{"sh", "-c", fmt.Sprintf("(sleep 1; touch %s; echo Late gauge 1) & wait", marker)}
+	source, err := NewExec("check", command, ExecFormatSimple, time.Second, time.Millisecond*100)
+	require.NoError(t, err)
+
+	start := time.Now()
+	got, err := source.Collect(context.Background())
+	require.NoError(t, err)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, []*metrics.Metric{newGauge("ExecSuccess", 0, map[string]string{"exec": "check"})}, got)
+
+	time.Sleep(time.Millisecond * 1500)
+	assert.NoFileExists(t, marker)
+}
@@ -0,0 +1,19 @@
+//go:build !windows
+// +build !windows
+
+package sources
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup запускает команду в новой группе процессов
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// killProcessGroup завершает все процессы группы, запущенной командой cmd
+func killProcessGroup(cmd *exec.Cmd) error {
+	return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+}
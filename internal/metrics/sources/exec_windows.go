@@ -0,0 +1,13 @@
+package sources
+
+import (
+	"os/exec"
+)
+
+// setProcessGroup ничего не делает: группы процессов не поддерживаются
+func setProcessGroup(_ *exec.Cmd) {}
+
+// killProcessGroup завершает процесс, запущенный командой cmd
+func killProcessGroup(cmd *exec.Cmd) error {
+	return cmd.Process.Kill()
+}
@@ -0,0 +1,184 @@
+package sources
+
+import (
+	"context"
+	"fmt"
+	"math"
+	rtmetrics "runtime/metrics"
+	"strings"
+	"time"
+
+	"github.com/hikjik/go-metrics/internal/metrics"
+)
+
+// Способы представления гистограмм пакета runtime/metrics
+const (
+	HistogramModeHistogram = "histogram"
+	HistogramModeSummary   = "summary"
+)
+
+// summaryQuantiles квантили, публикуемые для гистограмм в режиме HistogramModeSummary
+var summaryQuantiles = []struct {
+	suffix   string
+	quantile float64
+}{
+	{"_p50", 0.5},
+	{"_p90", 0.9},
+	{"_p99", 0.99},
+}
+
+// GoRuntime источник метрик пакета runtime/metrics. В отличие от runtime.ReadMemStats,
+// чтение метрик не останавливает программу, а набор метрик определяется версией Go.
+//
+// Имена метрик преобразуются к виду go_gc_heap_allocs_bytes. Накопленные целочисленные
+// значения возвращаются как счетчики, остальные - как метрики типа GaugeType.
+// Гистограммы возвращаются как метрики типа HistogramType или, в режиме HistogramModeSummary,
+// как метрики типа GaugeType с оценками квантилей и суффиксами _p50, _p90 и _p99.
+type GoRuntime struct {
+	descriptions []rtmetrics.Description
+	interval     time.Duration
+	summary      bool
+}
+
+// NewGoRuntime создает источник GoRuntime с периодом опроса interval.
+// Публикуются метрики, имена которых начинаются с одного из префиксов allow,
+// или все поддерживаемые метрики, если префиксы не заданы.
+func NewGoRuntime(interval time.Duration, allow []string, histogramMode string) (*GoRuntime, error) {
+	g := &GoRuntime{interval: interval}
+	switch histogramMode {
+	case "", HistogramModeHistogram:
+	case HistogramModeSummary:
+		g.summary = true
+	default:
+		return nil, fmt.Errorf("unknown histogram mode: %s", histogramMode)
+	}
+
+	for _, description := range rtmetrics.All() {
+		if description.Kind != rtmetrics.KindBad && allowed(description.Name, allow) {
+			g.descriptions = append(g.descriptions, description)
+		}
+	}
+	return g, nil
+}
+
+func (g *GoRuntime) Name() string {
+	return "go_runtime"
+}
+
+func (g *GoRuntime) Interval() time.Duration {
+	return g.interval
+}
+
+func (g *GoRuntime) StartsWithAgent() bool {
+	return true
+}
+
+func (g *GoRuntime) Collect(_ context.Context) ([]*metrics.Metric, error) {
+	samples := make([]rtmetrics.Sample, len(g.descriptions))
+	for i, description := range g.descriptions {
+		samples[i].Name = description.Name
+	}
+	rtmetrics.Read(samples)
+
+	collection := make([]*metrics.Metric, 0, len(samples))
+	for i, sample := range samples {
+		id := runtimeMetricID(sample.Name)
+		switch sample.Value.Kind() {
+		case rtmetrics.KindUint64:
+			value := sample.Value.Uint64()
+			if g.descriptions[i].Cumulative {
+				collection = append(collection, newCounter(id, value, nil))
+			} else {
+				collection = append(collection, metrics.NewGauge(id, float64(value)))
+			}
+		case rtmetrics.KindFloat64:
+			collection = append(collection, metrics.NewGauge(id, sample.Value.Float64()))
+		case rtmetrics.KindFloat64Histogram:
+			h := convertHistogram(sample.Value.Float64Histogram())
+			if !g.summary {
+				collection = append(collection, &metrics.Metric{ID: id, MType: metrics.HistogramType, Histogram: h})
+				continue
+			}
+			for _, q := range summaryQuantiles {
+				collection = append(collection, metrics.NewGauge(id+q.suffix, quantile(h, q.quantile)))
+			}
+		}
+	}
+	return collection, nil
+}
+
+// allowed проверяет, что имя метрики name начинается с одного из префиксов allow
+func allowed(name string, allow []string) bool {
+	if len(allow) == 0 {
+		return true
+	}
+	for _, prefix := range allow {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// runtimeMetricID преобразует имя метрики runtime/metrics вида /gc/heap/allocs:bytes
+// в имя go_gc_heap_allocs_bytes
+func runtimeMetricID(name string) string {
+	return "go" + strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// convertHistogram преобразует гистограмму runtime/metrics с интервалами [Buckets[i], Buckets[i+1])
+// в Histogram, границами интервалов которой становятся верхние границы всех интервалов, кроме последнего.
+// Сумма значений оценивается по серединам интервалов, для бесконечных интервалов - по конечной границе.
+func convertHistogram(rh *rtmetrics.Float64Histogram) *metrics.Histogram {
+	h := &metrics.Histogram{
+		Bounds: []float64{},
+		Counts: append([]uint64(nil), rh.Counts...),
+	}
+	if len(rh.Counts) == 0 {
+		h.Counts = []uint64{0}
+		return h
+	}
+	h.Bounds = append(h.Bounds, rh.Buckets[1:len(rh.Counts)]...)
+
+	for i, count := range rh.Counts {
+		h.Count += count
+		if count > 0 {
+			h.Sum += float64(count) * bucketValue(rh.Buckets[i], rh.Buckets[i+1])
+		}
+	}
+	return h
+}
+
+// bucketValue возвращает оценку значений интервала [lower, upper)
+func bucketValue(lower, upper float64) float64 {
+	switch {
+	case math.IsInf(lower, -1):
+		return upper
+	case math.IsInf(upper, 1):
+		return lower
+	default:
+		return (lower + upper) / 2
+	}
+}
+
+// quantile оценивает квантиль q гистограммы h верхней границей интервала,
+// в который он попадает. Для последнего интервала возвращается наибольшая граница.
+func quantile(h *metrics.Histogram, q float64) float64 {
+	if h.Count == 0 || len(h.Bounds) == 0 {
+		return 0
+	}
+	rank := uint64(math.Ceil(q * float64(h.Count)))
+	var cumulative uint64
+	for i, bound := range h.Bounds {
+		cumulative += h.Counts[i]
+		if cumulative >= rank {
+			return bound
+		}
+	}
+	return h.Bounds[len(h.Bounds)-1]
+}
@@ -0,0 +1,89 @@
+package sources
+
+import (
+	"context"
+	"math"
+	rtmetrics "runtime/metrics"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hikjik/go-metrics/internal/metrics"
+)
+
+func TestGoRuntime(t *testing.T) {
+	source, err := NewGoRuntime(time.Second, []string{"/sched/", "/gc/heap/allocs:bytes"}, HistogramModeHistogram)
+	require.NoError(t, err)
+	assert.True(t, metrics.StartsWithAgent(source))
+
+	collection, err := source.Collect(context.Background())
+	require.NoError(t, err)
+
+	found := make(map[string]*metrics.Metric)
+	for _, m := range collection {
+		found[m.ID] = m
+	}
+	require.Contains(t, found, "go_sched_goroutines_goroutines")
+	assert.Equal(t, metrics.GaugeType, found["go_sched_goroutines_goroutines"].MType)
+	assert.GreaterOrEqual(t, *found["go_sched_goroutines_goroutines"].Value, 1.0)
+
+	require.Contains(t, found, "go_gc_heap_allocs_bytes")
+	assert.Equal(t, metrics.CounterType, found["go_gc_heap_allocs_bytes"].MType)
+
+	require.Contains(t, found, "go_sched_latencies_seconds")
+	latencies := found["go_sched_latencies_seconds"]
+	assert.Equal(t, metrics.HistogramType, latencies.MType)
+	assert.True(t, latencies.Histogram.Valid())
+
+	for id := range found {
+		assert.Regexp(t, "^go_(sched|gc_heap_allocs_bytes)", id)
+	}
+}
+
+func TestGoRuntimeSummary(t *testing.T) {
+	source, err := NewGoRuntime(time.Second, []string{"/sched/latencies:seconds"}, HistogramModeSummary)
+	require.NoError(t, err)
+
+	collection, err := source.Collect(context.Background())
+	require.NoError(t, err)
+
+	var ids []string
+	for _, m := range collection {
+		assert.Equal(t, metrics.GaugeType, m.MType)
+		ids = append(ids, m.ID)
+	}
+	assert.Equal(t, []string{
+		"go_sched_latencies_seconds_p50",
+		"go_sched_latencies_seconds_p90",
+		"go_sched_latencies_seconds_p99",
+	}, ids)
+
+	_, err = NewGoRuntime(time.Second, nil, "unknown")
+	assert.Error(t, err)
+}
+
+func TestConvertHistogram(t *testing.T) {
+	h := convertHistogram(&rtmetrics.Float64Histogram{
+		Buckets: []float64{math.Inf(-1), 1, 2, 4, math.Inf(1)},
+		Counts:  []uint64{1, 2, 0, 1},
+	})
+	assert.Equal(t, &metrics.Histogram{
+		Bounds: []float64{1, 2, 4},
+		Counts: []uint64{1, 2, 0, 1},
+		Sum:    1 + 2*1.5 + 4,
+		Count:  4,
+	}, h)
+	assert.True(t, h.Valid())
+
+	assert.Equal(t, 2.0, quantile(h, 0.5))
+	assert.Equal(t, 4.0, quantile(h, 0.99))
+	assert.Equal(t, 1.0, quantile(h, 0.1))
+}
+
+func TestRuntimeMetricID(t *testing.T) {
+	assert.Equal(t, "go_gc_heap_allocs_bytes", runtimeMetricID("/gc/heap/allocs:bytes"))
+	assert.Equal(t, "go_godebug_non_default_behavior_http2client_events",
+		runtimeMetricID("/godebug/non-default-behavior/http2client:events"))
+}
@@ -0,0 +1,53 @@
+package sources
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/net"
+
+	"github.com/hikjik/go-metrics/internal/metrics"
+)
+
+// Network источник счетчиков сетевых интерфейсов.
+// Метрики каждого интерфейса содержат метку interface.
+type Network struct {
+	interval time.Duration
+}
+
+// NewNetwork создает источник Network с периодом опроса interval
+func NewNetwork(interval time.Duration) *Network {
+	return &Network{interval: interval}
+}
+
+func (n *Network) Name() string {
+	return "network"
+}
+
+func (n *Network) Interval() time.Duration {
+	return n.interval
+}
+
+func (n *Network) Collect(ctx context.Context) ([]*metrics.Metric, error) {
+	counters, err := net.IOCountersWithContext(ctx, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get network io counters: %w", err)
+	}
+
+	var collection []*metrics.Metric
+	for _, stat := range counters {
+		labels := map[string]string{"interface": stat.Name}
+		collection = append(collection,
+			newCounter("NetworkBytesSent", stat.BytesSent, labels),
+			newCounter("NetworkBytesRecv", stat.BytesRecv, labels),
+			newCounter("NetworkPacketsSent", stat.PacketsSent, labels),
+			newCounter("NetworkPacketsRecv", stat.PacketsRecv, labels),
+			newCounter("NetworkErrorsIn", stat.Errin, labels),
+			newCounter("NetworkErrorsOut", stat.Errout, labels),
+			newCounter("NetworkDropIn", stat.Dropin, labels),
+			newCounter("NetworkDropOut", stat.Dropout, labels),
+		)
+	}
+	return collection, nil
+}
@@ -0,0 +1,223 @@
+package sources
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/shirou/gopsutil/v3/process"
+
+	"github.com/hikjik/go-metrics/internal/metrics"
+)
+
+// Способы выбора процессов в правилах источника Process
+const (
+	ruleName    = "name"
+	ruleCmdline = "cmdline"
+	rulePIDFile = "pidfile"
+)
+
+// ProcessRule правило выбора процессов группы Group:
+// по имени процесса Name, регулярному выражению для командной строки Cmdline
+// или pid-файлу PIDFile. Задается только один из способов выбора.
+type ProcessRule struct {
+	Cmdline *regexp.Regexp
+	Group   string
+	Name    string
+	PIDFile string
+}
+
+// ParseProcessRule разбирает правило вида group:kind=value,
+// где kind - name, cmdline или pidfile
+func ParseProcessRule(s string) (ProcessRule, error) {
+	i := strings.IndexByte(s, ':')
+	if i <= 0 {
+		return ProcessRule{}, fmt.Errorf("invalid process rule %s: no group", s)
+	}
+	rule := ProcessRule{Group: s[:i]}
+
+	kind, value := splitKeyValue(s[i+1:])
+	if value == "" {
+		return ProcessRule{}, fmt.Errorf("invalid process rule %s: no value", s)
+	}
+	switch kind {
+	case ruleName:
+		rule.Name = value
+	case ruleCmdline:
+		re, err := regexp.Compile(value)
+		if err != nil {
+			return ProcessRule{}, fmt.Errorf("invalid process rule %s: %w", s, err)
+		}
+		rule.Cmdline = re
+	case rulePIDFile:
+		rule.PIDFile = value
+	default:
+		return ProcessRule{}, fmt.Errorf("invalid process rule %s: unknown kind %s", s, kind)
+	}
+	return rule, nil
+}
+
+// Process источник метрик процессов, выбранных правилами.
+// Метрики каждого процесса содержат метки group с именем группы правила, pid и name.
+// Для каждой группы также возвращается количество найденных процессов ProcessCount.
+//
+// Метка pid создает новую серию для каждого запуска процесса, и на сервере такие серии
+// хранятся бессрочно, поэтому правила следует задавать для долгоживущих процессов,
+// а не для часто перезапускаемых или короткоживущих.
+// Счетчики процессорного времени и ввода-вывода накоплены с момента запуска процесса,
+// поэтому источник не реализует metrics.AgentSource: агент отправляет на сервер
+// только приращения после первого опроса процесса.
+type Process struct {
+	rules    []ProcessRule
+	interval time.Duration
+}
+
+// NewProcess создает источник Process с периодом опроса interval и правилами выбора процессов rules
+func NewProcess(interval time.Duration, rules []string) (*Process, error) {
+	p := &Process{interval: interval}
+	for _, s := range rules {
+		rule, err := ParseProcessRule(s)
+		if err != nil {
+			return nil, err
+		}
+		p.rules = append(p.rules, rule)
+	}
+	return p, nil
+}
+
+func (p *Process) Name() string {
+	return "process"
+}
+
+func (p *Process) Interval() time.Duration {
+	return p.interval
+}
+
+func (p *Process) Collect(ctx context.Context) ([]*metrics.Metric, error) {
+	var processes []*process.Process
+	for _, rule := range p.rules {
+		if rule.PIDFile == "" {
+			var err error
+			if processes, err = process.ProcessesWithContext(ctx); err != nil {
+				return nil, fmt.Errorf("failed to list processes: %w", err)
+			}
+			break
+		}
+	}
+
+	var collection []*metrics.Metric
+	for _, rule := range p.rules {
+		matched, err := rule.match(ctx, processes)
+		if err != nil {
+			log.Warn().Err(err).Msgf("Failed to find processes of group %s", rule.Group)
+		}
+
+		count := 0
+		for _, proc := range matched {
+			m, err := processMetrics(ctx, rule.Group, proc)
+			if err != nil {
+				// процесс мог завершиться после получения списка процессов
+				log.Debug().Err(err).Msgf("Failed to collect metrics of process %d", proc.Pid)
+				continue
+			}
+			collection = append(collection, m...)
+			count++
+		}
+		collection = append(collection,
+			newGauge("ProcessCount", float64(count), map[string]string{"group": rule.Group}))
+	}
+	return collection, nil
+}
+
+// match возвращает процессы, соответствующие правилу
+func (r ProcessRule) match(ctx context.Context, processes []*process.Process) ([]*process.Process, error) {
+	if r.PIDFile != "" {
+		data, err := os.ReadFile(r.PIDFile)
+		if errors.Is(err, os.ErrNotExist) {
+			// процесс не запущен
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		pid, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pid file %s: %w", r.PIDFile, err)
+		}
+		proc, err := process.NewProcessWithContext(ctx, int32(pid))
+		if errors.Is(err, process.ErrorProcessNotRunning) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return []*process.Process{proc}, nil
+	}
+
+	var matched []*process.Process
+	for _, proc := range processes {
+		if r.Name != "" {
+			if name, err := proc.NameWithContext(ctx); err == nil && name == r.Name {
+				matched = append(matched, proc)
+			}
+			continue
+		}
+		if cmdline, err := proc.CmdlineWithContext(ctx); err == nil && r.Cmdline.MatchString(cmdline) {
+			matched = append(matched, proc)
+		}
+	}
+	return matched, nil
+}
+
+// processMetrics возвращает метрики процесса proc группы group.
+// Счетчики ввода-вывода, недоступные без дополнительных прав, пропускаются.
+func processMetrics(ctx context.Context, group string, proc *process.Process) ([]*metrics.Metric, error) {
+	name, err := proc.NameWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	memory, err := proc.MemoryInfoWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	times, err := proc.TimesWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	threads, err := proc.NumThreadsWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	labels := map[string]string{
+		"group": group,
+		"pid":   strconv.Itoa(int(proc.Pid)),
+		"name":  name,
+	}
+	collection := []*metrics.Metric{
+		newGauge("ProcessRSSBytes", float64(memory.RSS), labels),
+		newGauge("ProcessThreads", float64(threads), labels),
+		newCounter("ProcessCPUUserMs", uint64(math.Round(times.User*1000)), labels),
+		newCounter("ProcessCPUSystemMs", uint64(math.Round(times.System*1000)), labels),
+	}
+
+	if fds, err := proc.NumFDsWithContext(ctx); err == nil {
+		collection = append(collection, newGauge("ProcessOpenFDs", float64(fds), labels))
+	}
+	if io, err := proc.IOCountersWithContext(ctx); err == nil {
+		collection = append(collection,
+			newCounter("ProcessIOReads", io.ReadCount, labels),
+			newCounter("ProcessIOWrites", io.WriteCount, labels),
+			newCounter("ProcessIOReadBytes", io.ReadBytes, labels),
+			newCounter("ProcessIOWriteBytes", io.WriteBytes, labels),
+		)
+	}
+	return collection, nil
+}
@@ -0,0 +1,103 @@
+package sources
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hikjik/go-metrics/internal/metrics"
+)
+
+func TestParseProcessRule(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    string
+		want    ProcessRule
+		wantErr bool
+	}{
+		{
+			name: "Name",
+			rule: "web:name=nginx",
+			want: ProcessRule{Group: "web", Name: "nginx"},
+		},
+		{
+			name: "Pid file",
+			rule: "db:pidfile=/run/postgresql.pid",
+			want: ProcessRule{Group: "db", PIDFile: "/run/postgresql.pid"},
+		},
+		{
+			name:    "No group",
+			rule:    "name=nginx",
+			wantErr: true,
+		},
+		{
+			name:    "No value",
+			rule:    "web:name=",
+			wantErr: true,
+		},
+		{
+			name:    "Unknown kind",
+			rule:    "web:user=www",
+			wantErr: true,
+		},
+		{
+			name:    "Invalid regexp",
+			rule:    "api:cmdline=java(",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseProcessRule(tt.rule)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	rule, err := ParseProcessRule("api:cmdline=java .*-jar api.jar")
+	require.NoError(t, err)
+	assert.True(t, rule.Cmdline.MatchString("/usr/bin/java -Xmx1g -jar api.jar"))
+}
+
+func TestProcess(t *testing.T) {
+	pidFile := filepath.Join(t.TempDir(), "agent.pid")
+	require.NoError(t, os.WriteFile(pidFile, []byte(strconv.Itoa(os.Getpid())+"\n"), 0644))
+
+	source, err := NewProcess(time.Second, []string{
+		"self:pidfile=" + pidFile,
+		"test:cmdline=^" + regexp.QuoteMeta(os.Args[0]),
+		"stopped:pidfile=" + filepath.Join(t.TempDir(), "missing.pid"),
+	})
+	require.NoError(t, err)
+	assert.False(t, metrics.StartsWithAgent(source))
+
+	collection, err := source.Collect(context.Background())
+	require.NoError(t, err)
+
+	found := make(map[string]*metrics.Metric)
+	for _, m := range collection {
+		found[m.ID+"/"+m.Labels["group"]] = m
+	}
+	for _, group := range []string{"self", "test"} {
+		require.Contains(t, found, "ProcessCount/"+group)
+		assert.GreaterOrEqual(t, *found["ProcessCount/"+group].Value, 1.0)
+
+		require.Contains(t, found, "ProcessRSSBytes/"+group)
+		rss := found["ProcessRSSBytes/"+group]
+		assert.Greater(t, *rss.Value, 0.0)
+		assert.Equal(t, strconv.Itoa(os.Getpid()), found["ProcessThreads/"+group].Labels["pid"])
+		assert.Contains(t, found, "ProcessCPUUserMs/"+group)
+	}
+	assert.Equal(t, 0.0, *found["ProcessCount/stopped"].Value)
+}
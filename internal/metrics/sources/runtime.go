@@ -0,0 +1,89 @@
+package sources
+
+import (
+	"context"
+	"math/rand"
+	"runtime"
+	"sync/atomic"
+	"time"
+
+	"github.com/hikjik/go-metrics/internal/metrics"
+)
+
+// Runtime источник счетчика опросов PollCount и метрики RandomValue,
+// а также рантайм-метрик, получаемых из runtime.MemStats.
+// Чтение runtime.MemStats останавливает программу, поэтому по умолчанию
+// эти метрики не собираются, и рантайм-метрики собирает источник GoRuntime.
+type Runtime struct {
+	pollCount int64
+	interval  time.Duration
+	memStats  bool
+}
+
+// NewRuntime создает источник Runtime с периодом опроса interval.
+// Метрики runtime.MemStats собираются, только если задан memStats.
+func NewRuntime(interval time.Duration, memStats bool) *Runtime {
+	return &Runtime{interval: interval, memStats: memStats}
+}
+
+func (r *Runtime) Name() string {
+	return "runtime"
+}
+
+func (r *Runtime) Interval() time.Duration {
+	return r.interval
+}
+
+func (r *Runtime) StartsWithAgent() bool {
+	return true
+}
+
+func (r *Runtime) Collect(_ context.Context) ([]*metrics.Metric, error) {
+	pollCount := atomic.AddInt64(&r.pollCount, 1)
+	if !r.memStats {
+		return []*metrics.Metric{
+			metrics.NewCounter("PollCount", pollCount),
+			metrics.NewGauge("RandomValue", rand.Float64()),
+		}, nil
+	}
+
+	memStats := &runtime.MemStats{}
+	runtime.ReadMemStats(memStats)
+	values := map[string]float64{
+		"RandomValue":   rand.Float64(),
+		"GCCPUFraction": memStats.GCCPUFraction,
+		"Alloc":         float64(memStats.Alloc),
+		"BuckHashSys":   float64(memStats.BuckHashSys),
+		"Frees":         float64(memStats.Frees),
+		"GCSys":         float64(memStats.GCSys),
+		"HeapAlloc":     float64(memStats.HeapAlloc),
+		"HeapIdle":      float64(memStats.HeapIdle),
+		"HeapInuse":     float64(memStats.HeapInuse),
+		"HeapObjects":   float64(memStats.HeapObjects),
+		"HeapReleased":  float64(memStats.HeapReleased),
+		"HeapSys":       float64(memStats.HeapSys),
+		"LastGC":        float64(memStats.LastGC),
+		"Lookups":       float64(memStats.Lookups),
+		"MCacheInuse":   float64(memStats.MCacheInuse),
+		"MCacheSys":     float64(memStats.MCacheSys),
+		"MSpanInuse":    float64(memStats.MSpanInuse),
+		"MSpanSys":      float64(memStats.MSpanSys),
+		"Mallocs":       float64(memStats.Mallocs),
+		"NextGC":        float64(memStats.NextGC),
+		"NumForcedGC":   float64(memStats.NumForcedGC),
+		"NumGC":         float64(memStats.NumGC),
+		"OtherSys":      float64(memStats.OtherSys),
+		"PauseTotalNs":  float64(memStats.PauseTotalNs),
+		"StackInuse":    float64(memStats.StackInuse),
+		"StackSys":      float64(memStats.StackSys),
+		"Sys":           float64(memStats.Sys),
+		"TotalAlloc":    float64(memStats.TotalAlloc),
+	}
+
+	collection := make([]*metrics.Metric, 0, len(values)+1)
+	collection = append(collection, metrics.NewCounter("PollCount", pollCount))
+	for id, value := range values {
+		collection = append(collection, metrics.NewGauge(id, value))
+	}
+	return collection, nil
+}
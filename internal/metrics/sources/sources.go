@@ -0,0 +1,91 @@
+// Package sources содержит источники метрик агента.
+package sources
+
+import (
+	"time"
+
+	"github.com/hikjik/go-metrics/internal/config"
+	"github.com/hikjik/go-metrics/internal/metrics"
+)
+
+// New возвращает источники метрик, включенные в настройках агента cfg.
+// Для источников без собственного периода опроса используется cfg.PollInterval.
+func New(cfg config.AgentConfig) ([]metrics.Source, error) {
+	interval := func(source config.SourceConfig) time.Duration {
+		if source.Interval > 0 {
+			return source.Interval
+		}
+		return cfg.PollInterval
+	}
+
+	var sources []metrics.Source
+	if runtime := cfg.Sources.Runtime; runtime.Enabled {
+		sources = append(sources, NewRuntime(interval(runtime.SourceConfig), runtime.MemStats))
+	}
+	if cfg.Sources.Utilization.Enabled {
+		sources = append(sources, NewUtilization(interval(cfg.Sources.Utilization)))
+	}
+	if cfg.Sources.Disk.Enabled {
+		sources = append(sources, NewDisk(interval(cfg.Sources.Disk)))
+	}
+	if cfg.Sources.Filesystem.Enabled {
+		sources = append(sources, NewFilesystem(interval(cfg.Sources.Filesystem)))
+	}
+	if cfg.Sources.Network.Enabled {
+		sources = append(sources, NewNetwork(interval(cfg.Sources.Network)))
+	}
+	if cfg.Sources.Load.Enabled {
+		sources = append(sources, NewLoad(interval(cfg.Sources.Load)))
+	}
+	if cfg.Sources.Uptime.Enabled {
+		sources = append(sources, NewUptime(interval(cfg.Sources.Uptime)))
+	}
+	if cfg.Sources.Swap.Enabled {
+		sources = append(sources, NewSwap(interval(cfg.Sources.Swap)))
+	}
+	if cgroup := cfg.Sources.Cgroup; cgroup.Enabled {
+		sources = append(sources, NewCgroup(interval(cgroup.SourceConfig), cgroup.Root, cgroup.Paths))
+	}
+	if goRuntime := cfg.Sources.GoRuntime; goRuntime.Enabled {
+		source, err := NewGoRuntime(interval(goRuntime.SourceConfig), goRuntime.Metrics, goRuntime.HistogramMode)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, source)
+	}
+	if proc := cfg.Sources.Process; proc.Enabled {
+		source, err := NewProcess(interval(proc.SourceConfig), proc.Rules)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, source)
+	}
+	for _, command := range cfg.Sources.Exec {
+		period := interval(config.SourceConfig{Interval: command.Interval})
+		timeout := command.Timeout
+		if timeout <= 0 {
+			timeout = period
+		}
+		source, err := NewExec(command.Name, command.Command, command.Format, period, timeout)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, source)
+	}
+	return sources, nil
+}
+
+// newCounter создает счетчик с накопленным значением value и метками labels.
+// Приращения счетчика вычисляет агент перед отправкой.
+func newCounter(id string, value uint64, labels map[string]string) *metrics.Metric {
+	m := metrics.NewCounter(id, int64(value))
+	m.Labels = labels
+	return m
+}
+
+// newGauge создает метрику типа GaugeType с метками labels
+func newGauge(id string, value float64, labels map[string]string) *metrics.Metric {
+	m := metrics.NewGauge(id, value)
+	m.Labels = labels
+	return m
+}
@@ -0,0 +1,103 @@
+package sources
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hikjik/go-metrics/internal/config"
+	"github.com/hikjik/go-metrics/internal/metrics"
+)
+
+func TestNew(t *testing.T) {
+	cfg := config.AgentConfig{
+		PollInterval: time.Second * 2,
+		Sources: config.SourcesConfig{
+			Runtime:     config.RuntimeSourceConfig{SourceConfig: config.SourceConfig{Enabled: true, Interval: time.Second * 5}},
+			Utilization: config.SourceConfig{Enabled: true},
+			Network:     config.SourceConfig{Enabled: true, Interval: time.Second * 10},
+		},
+	}
+	sources, err := New(cfg)
+	require.NoError(t, err)
+	require.Len(t, sources, 3)
+	assert.Equal(t, "runtime", sources[0].Name())
+	assert.Equal(t, time.Second*5, sources[0].Interval())
+	assert.Equal(t, "utilization", sources[1].Name())
+	assert.Equal(t, time.Second*2, sources[1].Interval())
+	assert.Equal(t, "network", sources[2].Name())
+	assert.Equal(t, time.Second*10, sources[2].Interval())
+
+	cfg.Sources.Utilization.Enabled = false
+	sources, err = New(cfg)
+	require.NoError(t, err)
+	assert.Len(t, sources, 2)
+
+	cfg.Sources.Exec = []config.ExecSourceConfig{{Name: "queue", Command: []string{"queue-depth"}}}
+	sources, err = New(cfg)
+	require.NoError(t, err)
+	require.Len(t, sources, 3)
+	assert.Equal(t, "exec_queue", sources[2].Name())
+	assert.Equal(t, time.Second*2, sources[2].Interval())
+
+	cfg.Sources.Exec = []config.ExecSourceConfig{{Name: "queue"}}
+	_, err = New(cfg)
+	assert.Error(t, err)
+
+	cfg.Sources.Exec = nil
+	cfg.Sources.Process = config.ProcessSourceConfig{
+		Rules:        []string{"web:unknown=nginx"},
+		SourceConfig: config.SourceConfig{Enabled: true},
+	}
+	_, err = New(cfg)
+	assert.Error(t, err)
+}
+
+func TestRuntime(t *testing.T) {
+	source := NewRuntime(time.Second, false)
+	assert.True(t, metrics.StartsWithAgent(source))
+	for i := int64(1); i <= 2; i++ {
+		collection, err := source.Collect(context.Background())
+		require.NoError(t, err)
+		require.Len(t, collection, 2)
+		assert.Equal(t, metrics.NewCounter("PollCount", i), collection[0])
+		assert.Equal(t, "RandomValue", collection[1].ID)
+	}
+
+	collection, err := NewRuntime(time.Second, true).Collect(context.Background())
+	require.NoError(t, err)
+	assert.Greater(t, len(collection), 2)
+	assert.Equal(t, metrics.NewCounter("PollCount", 1), collection[0])
+}
+
+func TestHostSources(t *testing.T) {
+	for _, source := range []metrics.Source{
+		NewUtilization(time.Second),
+		NewDisk(time.Second),
+		NewFilesystem(time.Second),
+		NewNetwork(time.Second),
+		NewLoad(time.Second),
+		NewUptime(time.Second),
+		NewSwap(time.Second),
+	} {
+		t.Run(source.Name(), func(t *testing.T) {
+			assert.False(t, metrics.StartsWithAgent(source))
+			collection, err := source.Collect(context.Background())
+			require.NoError(t, err)
+			for _, m := range collection {
+				assert.True(t, metrics.ValidateLabels(m.Labels), m.SeriesKey())
+				switch m.MType {
+				case metrics.CounterType:
+					assert.GreaterOrEqual(t, *m.Delta, int64(0), m.SeriesKey())
+				case metrics.GaugeType:
+					assert.NotNil(t, m.Value, m.SeriesKey())
+				default:
+					t.Errorf("unexpected type %s of %s", m.MType, m.SeriesKey())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package sources
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/host"
+	"github.com/shirou/gopsutil/v3/load"
+	"github.com/shirou/gopsutil/v3/mem"
+
+	"github.com/hikjik/go-metrics/internal/metrics"
+)
+
+// Load источник средней загрузки системы за 1, 5 и 15 минут
+type Load struct {
+	interval time.Duration
+}
+
+// NewLoad создает источник Load с периодом опроса interval
+func NewLoad(interval time.Duration) *Load {
+	return &Load{interval: interval}
+}
+
+func (l *Load) Name() string {
+	return "load"
+}
+
+func (l *Load) Interval() time.Duration {
+	return l.interval
+}
+
+func (l *Load) Collect(ctx context.Context) ([]*metrics.Metric, error) {
+	avg, err := load.AvgWithContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get load average: %w", err)
+	}
+	return []*metrics.Metric{
+		metrics.NewGauge("Load1", avg.Load1),
+		metrics.NewGauge("Load5", avg.Load5),
+		metrics.NewGauge("Load15", avg.Load15),
+	}, nil
+}
+
+// Uptime источник времени работы системы в секундах
+type Uptime struct {
+	interval time.Duration
+}
+
+// NewUptime создает источник Uptime с периодом опроса interval
+func NewUptime(interval time.Duration) *Uptime {
+	return &Uptime{interval: interval}
+}
+
+func (u *Uptime) Name() string {
+	return "uptime"
+}
+
+func (u *Uptime) Interval() time.Duration {
+	return u.interval
+}
+
+func (u *Uptime) Collect(ctx context.Context) ([]*metrics.Metric, error) {
+	uptime, err := host.UptimeWithContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get uptime: %w", err)
+	}
+	return []*metrics.Metric{metrics.NewGauge("UptimeSeconds", float64(uptime))}, nil
+}
+
+// Swap источник метрик использования файла подкачки
+type Swap struct {
+	interval time.Duration
+}
+
+// NewSwap создает источник Swap с периодом опроса interval
+func NewSwap(interval time.Duration) *Swap {
+	return &Swap{interval: interval}
+}
+
+func (s *Swap) Name() string {
+	return "swap"
+}
+
+func (s *Swap) Interval() time.Duration {
+	return s.interval
+}
+
+func (s *Swap) Collect(ctx context.Context) ([]*metrics.Metric, error) {
+	swap, err := mem.SwapMemoryWithContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get swap stats: %w", err)
+	}
+	return []*metrics.Metric{
+		metrics.NewGauge("SwapTotal", float64(swap.Total)),
+		metrics.NewGauge("SwapUsed", float64(swap.Used)),
+		metrics.NewGauge("SwapFree", float64(swap.Free)),
+		newCounter("SwapInBytes", swap.Sin, nil),
+		newCounter("SwapOutBytes", swap.Sout, nil),
+	}, nil
+}
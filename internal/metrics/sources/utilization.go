@@ -0,0 +1,51 @@
+package sources
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/cpu"
+	"github.com/shirou/gopsutil/v3/mem"
+
+	"github.com/hikjik/go-metrics/internal/metrics"
+)
+
+// Utilization источник метрик использования памяти и процессора, получаемых с помощью пакета gopsutil
+type Utilization struct {
+	interval time.Duration
+}
+
+// NewUtilization создает источник Utilization с периодом опроса interval
+func NewUtilization(interval time.Duration) *Utilization {
+	return &Utilization{interval: interval}
+}
+
+func (u *Utilization) Name() string {
+	return "utilization"
+}
+
+func (u *Utilization) Interval() time.Duration {
+	return u.interval
+}
+
+func (u *Utilization) Collect(ctx context.Context) ([]*metrics.Metric, error) {
+	v, err := mem.VirtualMemoryWithContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get memory stats: %w", err)
+	}
+
+	usage, err := cpu.PercentWithContext(ctx, 0, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cpu stats: %w", err)
+	}
+
+	collection := []*metrics.Metric{
+		metrics.NewGauge("TotalMemory", float64(v.Total)),
+		metrics.NewGauge("FreeMemory", float64(v.Free)),
+	}
+	for i, value := range usage {
+		collection = append(collection, metrics.NewGauge(fmt.Sprintf("CPUutilization%d", i), value))
+	}
+	return collection, nil
+}
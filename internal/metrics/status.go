@@ -0,0 +1,29 @@
+package metrics
+
+// Status содержит результат сохранения метрики на сервере.
+// Пустое поле Error означает, что метрика принята, иначе оно содержит причину отказа.
+type Status struct {
+	Labels map[string]string `json:"labels,omitempty"`
+	ID     string            `json:"id"`
+	MType  string            `json:"type"`
+	Error  string            `json:"error,omitempty"`
+}
+
+// NewStatus возвращает результат сохранения метрики m.
+// Ошибка err, отличная от nil, означает отказ в сохранении метрики.
+func NewStatus(m *Metric, err error) Status {
+	status := Status{
+		ID:     m.ID,
+		MType:  m.MType,
+		Labels: m.Labels,
+	}
+	if err != nil {
+		status.Error = err.Error()
+	}
+	return status
+}
+
+// Accepted возвращает true, если метрика принята сервером
+func (s Status) Accepted() bool {
+	return s.Error == ""
+}
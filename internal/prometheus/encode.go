@@ -0,0 +1,196 @@
+// Package prometheus реализует текстовый формат представления метрик Prometheus.
+package prometheus
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/hikjik/go-metrics/internal/metrics"
+)
+
+// ContentType тип содержимого текстового формата Prometheus
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Encode записывает метрики в w в текстовом формате Prometheus.
+// Метрики типа CounterType публикуются как счетчики с суффиксом _total,
+// метрики типа GaugeType - как gauge, метрики типа HistogramType - как histogram
+// с накопленными значениями интервалов _bucket, суммой _sum и количеством _count. Временные ряды одной метрики,
+// различающиеся набором меток, объединяются под общими строками HELP и TYPE.
+// Если имена разных метрик совпадают после преобразования, например, у gauge X_total
+// и счетчика X, публикуется только первая из них в порядке типа и имени.
+func Encode(w io.Writer, collection []*metrics.Metric) error {
+	sorted := make([]*metrics.Metric, len(collection))
+	copy(sorted, collection)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if fi, fj := familyName(sorted[i]), familyName(sorted[j]); fi != fj {
+			return fi < fj
+		}
+		if sorted[i].MType != sorted[j].MType {
+			return sorted[i].MType < sorted[j].MType
+		}
+		if sorted[i].ID != sorted[j].ID {
+			return sorted[i].ID < sorted[j].ID
+		}
+		return metrics.EncodeLabels(sorted[i].Labels) < metrics.EncodeLabels(sorted[j].Labels)
+	})
+
+	buf := bufio.NewWriter(w)
+	var prev *metrics.Metric
+	for _, metric := range sorted {
+		name := familyName(metric)
+		switch {
+		case prev == nil || name != familyName(prev):
+			writeHeader(buf, name, metric.ID, metric.MType)
+			prev = metric
+		case metric.ID != prev.ID || metric.MType != prev.MType:
+			log.Warn().Msgf("Skipped metric %s of type %s: name %s is already used by metric %s of type %s",
+				metric.ID, metric.MType, name, prev.ID, prev.MType)
+			continue
+		}
+		if err := writeMetric(buf, name, metric); err != nil {
+			return err
+		}
+	}
+	return buf.Flush()
+}
+
+func writeMetric(w *bufio.Writer, name string, metric *metrics.Metric) error {
+	labels := formatLabels(metric.Labels)
+	switch metric.MType {
+	case metrics.CounterType:
+		if metric.Delta == nil {
+			return fmt.Errorf("counter %s has no value", metric.ID)
+		}
+		_, err := fmt.Fprintf(w, "%s%s %d\n", name, labels, *metric.Delta)
+		return err
+	case metrics.GaugeType:
+		if metric.Value == nil {
+			return fmt.Errorf("gauge %s has no value", metric.ID)
+		}
+		_, err := fmt.Fprintf(w, "%s%s %s\n", name, labels, formatFloat(*metric.Value))
+		return err
+	case metrics.HistogramType:
+		if metric.Histogram == nil {
+			return fmt.Errorf("histogram %s has no value", metric.ID)
+		}
+		return writeHistogram(w, name, metric)
+	default:
+		return fmt.Errorf("unknown metric type: %s", metric.MType)
+	}
+}
+
+func writeHistogram(w *bufio.Writer, name string, metric *metrics.Metric) error {
+	h := metric.Histogram
+	var cumulative uint64
+	for i, bound := range h.Bounds {
+		cumulative += h.Counts[i]
+		fmt.Fprintf(w, "%s_bucket%s %d\n",
+			name, formatLabels(metric.Labels, formatFloat(bound)), cumulative)
+	}
+	labels := formatLabels(metric.Labels)
+	fmt.Fprintf(w, "%s_bucket%s %d\n", name, formatLabels(metric.Labels, "+Inf"), h.Count)
+	fmt.Fprintf(w, "%s_sum%s %s\n", name, labels, formatFloat(h.Sum))
+	_, err := fmt.Fprintf(w, "%s_count%s %d\n", name, labels, h.Count)
+	return err
+}
+
+// formatLabels возвращает набор меток в формате Prometheus, упорядоченный по имени.
+// Для интервалов гистограммы последней добавляется метка le с верхней границей интервала.
+func formatLabels(labels map[string]string, le ...string) string {
+	if len(labels) == 0 && len(le) == 0 {
+		return ""
+	}
+
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, name := range names {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(sanitizeName(name))
+		b.WriteString(`="`)
+		b.WriteString(escapeLabelValue(labels[name]))
+		b.WriteByte('"')
+	}
+	for _, bound := range le {
+		if len(names) > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(`le="`)
+		b.WriteString(bound)
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+func writeHeader(w *bufio.Writer, name, id, mType string) {
+	fmt.Fprintf(w, "# HELP %s Metric %s of type %s.\n", name, escapeHelp(id), mType)
+	fmt.Fprintf(w, "# TYPE %s %s\n", name, mType)
+}
+
+// familyName возвращает имя метрики, допустимое в формате Prometheus
+func familyName(metric *metrics.Metric) string {
+	name := sanitizeName(metric.ID)
+	if metric.MType == metrics.CounterType && !strings.HasSuffix(name, "_total") {
+		name += "_total"
+	}
+	return name
+}
+
+// sanitizeName заменяет недопустимые символы имени метрики на '_'
+func sanitizeName(id string) string {
+	if id == "" {
+		return "_"
+	}
+
+	var b strings.Builder
+	for i, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r == '_', r == ':':
+			b.WriteRune(r)
+		case r >= '0' && r <= '9':
+			if i == 0 {
+				b.WriteRune('_')
+			}
+			b.WriteRune(r)
+		default:
+			b.WriteRune('_')
+		}
+	}
+	return b.String()
+}
+
+func escapeLabelValue(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`).Replace(s)
+}
+
+func escapeHelp(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(s)
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsNaN(v):
+		return "NaN"
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
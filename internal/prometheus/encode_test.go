@@ -0,0 +1,124 @@
+package prometheus
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"github.com/openlyinc/pointy"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hikjik/go-metrics/internal/metrics"
+)
+
+func TestEncode(t *testing.T) {
+	tests := []struct {
+		name       string
+		want       string
+		collection []*metrics.Metric
+		wantErr    bool
+	}{
+		{
+			name:       "Empty",
+			collection: nil,
+			want:       "",
+		},
+		{
+			name: "Gauge and counter",
+			collection: []*metrics.Metric{
+				metrics.NewGauge("Alloc", 1.5),
+				metrics.NewCounter("PollCount", 3),
+			},
+			want: "# HELP Alloc Metric Alloc of type gauge.\n" +
+				"# TYPE Alloc gauge\n" +
+				"Alloc 1.5\n" +
+				"# HELP PollCount_total Metric PollCount of type counter.\n" +
+				"# TYPE PollCount_total counter\n" +
+				"PollCount_total 3\n",
+		},
+		{
+			name: "Sanitize name",
+			collection: []*metrics.Metric{
+				metrics.NewGauge("0cpu.usage", math.Inf(1)),
+			},
+			want: "# HELP _0cpu_usage Metric 0cpu.usage of type gauge.\n" +
+				"# TYPE _0cpu_usage gauge\n" +
+				"_0cpu_usage +Inf\n",
+		},
+		{
+			name: "Counter with total suffix",
+			collection: []*metrics.Metric{
+				metrics.NewCounter("requests_total", 1),
+			},
+			want: "# HELP requests_total Metric requests_total of type counter.\n" +
+				"# TYPE requests_total counter\n" +
+				"requests_total 1\n",
+		},
+		{
+			name: "Name collision",
+			collection: []*metrics.Metric{
+				metrics.NewGauge("requests_total", 2),
+				metrics.NewCounter("requests", 1),
+				metrics.NewGauge("cpu.usage", 0.5),
+				metrics.NewGauge("cpu_usage", 0.7),
+			},
+			want: "# HELP cpu_usage Metric cpu.usage of type gauge.\n" +
+				"# TYPE cpu_usage gauge\n" +
+				"cpu_usage 0.5\n" +
+				"# HELP requests_total Metric requests of type counter.\n" +
+				"# TYPE requests_total counter\n" +
+				"requests_total 1\n",
+		},
+		{
+			name: "Labels",
+			collection: []*metrics.Metric{
+				{ID: "Alloc", MType: metrics.GaugeType, Value: pointy.Float64(2), Labels: map[string]string{"host": "b"}},
+				{ID: "Alloc", MType: metrics.GaugeType, Value: pointy.Float64(1), Labels: map[string]string{"host": "a", "path": `"C:\"`}},
+			},
+			want: "# HELP Alloc Metric Alloc of type gauge.\n" +
+				"# TYPE Alloc gauge\n" +
+				`Alloc{host="a",path="\"C:\\\""} 1` + "\n" +
+				`Alloc{host="b"} 2` + "\n",
+		},
+		{
+			name: "Histogram",
+			collection: []*metrics.Metric{
+				{
+					ID: "Latency", MType: metrics.HistogramType,
+					Labels: map[string]string{"host": "a"},
+					Histogram: &metrics.Histogram{
+						Bounds: []float64{0.1, 1},
+						Counts: []uint64{1, 2, 3},
+						Sum:    10.5,
+						Count:  6,
+					},
+				},
+			},
+			want: "# HELP Latency Metric Latency of type histogram.\n" +
+				"# TYPE Latency histogram\n" +
+				`Latency_bucket{host="a",le="0.1"} 1` + "\n" +
+				`Latency_bucket{host="a",le="1"} 3` + "\n" +
+				`Latency_bucket{host="a",le="+Inf"} 6` + "\n" +
+				`Latency_sum{host="a"} 10.5` + "\n" +
+				`Latency_count{host="a"} 6` + "\n",
+		},
+		{
+			name:       "Unknown type",
+			collection: []*metrics.Metric{{ID: "Unknown", MType: "unknown"}},
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := Encode(&buf, tt.collection)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, buf.String())
+		})
+	}
+}
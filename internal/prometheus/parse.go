@@ -0,0 +1,175 @@
+package prometheus
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/hikjik/go-metrics/internal/metrics"
+)
+
+// ErrInvalidFormat возвращается при разборе строки, не соответствующей текстовому формату Prometheus
+var ErrInvalidFormat = errors.New("invalid prometheus text format")
+
+// Типы метрик текстового формата Prometheus
+const (
+	typeCounter   = "counter"
+	typeGauge     = "gauge"
+	typeHistogram = "histogram"
+	typeSummary   = "summary"
+)
+
+// Parse разбирает метрики в текстовом формате Prometheus.
+// Счетчики возвращаются как метрики типа CounterType с накопленным значением,
+// округленным до целого, суффикс _total отбрасывается. Метрики типа gauge и метрики
+// без указанного типа возвращаются как GaugeType. Гистограммы и summary,
+// а также нечисловые и бесконечные значения пропускаются. Временные метки игнорируются.
+func Parse(r io.Reader) ([]*metrics.Metric, error) {
+	types := make(map[string]string)
+	var collection []*metrics.Metric
+
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			fields := strings.Fields(line)
+			if len(fields) >= 4 && fields[1] == "TYPE" {
+				types[fields[2]] = fields[3]
+			}
+			continue
+		}
+
+		name, labels, value, err := parseSample(line)
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: %v", ErrInvalidFormat, n, err)
+		}
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			continue
+		}
+
+		var m *metrics.Metric
+		switch familyType(types, name) {
+		case typeCounter:
+			m = metrics.NewCounter(strings.TrimSuffix(name, "_total"), int64(math.Round(value)))
+		case typeHistogram, typeSummary:
+			continue
+		default:
+			m = metrics.NewGauge(name, value)
+		}
+		m.Labels = labels
+		collection = append(collection, m)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return collection, nil
+}
+
+// familyType возвращает тип семейства, к которому относится временной ряд name
+func familyType(types map[string]string, name string) string {
+	if t, ok := types[name]; ok {
+		return t
+	}
+	for _, suffix := range []string{"_total", "_bucket", "_sum", "_count", "_created"} {
+		if strings.HasSuffix(name, suffix) {
+			if t, ok := types[strings.TrimSuffix(name, suffix)]; ok {
+				return t
+			}
+		}
+	}
+	return ""
+}
+
+// parseSample разбирает строку вида name{label="value",...} value [timestamp]
+func parseSample(line string) (string, map[string]string, float64, error) {
+	end := strings.IndexAny(line, "{ \t")
+	if end <= 0 {
+		return "", nil, 0, fmt.Errorf("no value in %q", line)
+	}
+	name, rest := line[:end], line[end:]
+
+	var labels map[string]string
+	if strings.HasPrefix(rest, "{") {
+		var err error
+		if labels, rest, err = parseLabels(rest[1:]); err != nil {
+			return "", nil, 0, err
+		}
+	}
+
+	fields := strings.Fields(rest)
+	if len(fields) == 0 || len(fields) > 2 {
+		return "", nil, 0, fmt.Errorf("invalid value in %q", line)
+	}
+	value, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return "", nil, 0, fmt.Errorf("invalid value %q", fields[0])
+	}
+	return name, labels, value, nil
+}
+
+// parseLabels разбирает набор меток до закрывающей фигурной скобки
+// и возвращает оставшуюся часть строки
+func parseLabels(s string) (map[string]string, string, error) {
+	labels := make(map[string]string)
+	for {
+		s = strings.TrimLeft(s, " \t")
+		if strings.HasPrefix(s, "}") {
+			return labels, s[1:], nil
+		}
+
+		eq := strings.IndexByte(s, '=')
+		if eq <= 0 {
+			return nil, "", fmt.Errorf("invalid labels")
+		}
+		name := strings.TrimSpace(s[:eq])
+		s = strings.TrimLeft(s[eq+1:], " \t")
+		if !strings.HasPrefix(s, `"`) {
+			return nil, "", fmt.Errorf("unquoted value of label %s", name)
+		}
+
+		value, n, err := unquote(s[1:])
+		if err != nil {
+			return nil, "", fmt.Errorf("label %s: %v", name, err)
+		}
+		labels[name] = value
+		s = strings.TrimLeft(s[1+n:], " \t")
+		if strings.HasPrefix(s, ",") {
+			s = s[1:]
+		}
+	}
+}
+
+// unquote возвращает значение метки до закрывающей кавычки
+// и количество прочитанных байт, включая кавычку
+func unquote(s string) (string, int, error) {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '"':
+			return b.String(), i + 1, nil
+		case '\\':
+			i++
+			if i == len(s) {
+				return "", 0, fmt.Errorf("unterminated escape")
+			}
+			switch s[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case '\\', '"':
+				b.WriteByte(s[i])
+			default:
+				return "", 0, fmt.Errorf("invalid escape \\%c", s[i])
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated value")
+}
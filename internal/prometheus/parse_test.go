@@ -0,0 +1,118 @@
+package prometheus
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/openlyinc/pointy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hikjik/go-metrics/internal/metrics"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		want    []*metrics.Metric
+		wantErr bool
+	}{
+		{
+			name: "Empty",
+			text: "",
+			want: nil,
+		},
+		{
+			name: "Counter and gauge",
+			text: "# HELP http_requests_total Total requests.\n" +
+				"# TYPE http_requests_total counter\n" +
+				`http_requests_total{method="post",code="200"} 1027 1395066363000` + "\n" +
+				"# TYPE temperature gauge\n" +
+				"temperature 36.6\n",
+			want: []*metrics.Metric{
+				{ID: "http_requests", MType: metrics.CounterType, Delta: pointy.Int64(1027),
+					Labels: map[string]string{"method": "post", "code": "200"}},
+				{ID: "temperature", MType: metrics.GaugeType, Value: pointy.Float64(36.6)},
+			},
+		},
+		{
+			name: "Untyped and escaped labels",
+			text: `queue_size{path="C:\\dir",msg="a \"b\"\nc"} 2` + "\n",
+			want: []*metrics.Metric{
+				{ID: "queue_size", MType: metrics.GaugeType, Value: pointy.Float64(2),
+					Labels: map[string]string{"path": `C:\dir`, "msg": "a \"b\"\nc"}},
+			},
+		},
+		{
+			name: "Skip histograms and summaries",
+			text: "# TYPE latency histogram\n" +
+				`latency_bucket{le="0.1"} 3` + "\n" +
+				`latency_bucket{le="+Inf"} 5` + "\n" +
+				"latency_sum 1.5\n" +
+				"latency_count 5\n" +
+				"# TYPE rpc summary\n" +
+				`rpc{quantile="0.5"} 0.2` + "\n" +
+				"rpc_count 4\n" +
+				"up 1\n",
+			want: []*metrics.Metric{
+				{ID: "up", MType: metrics.GaugeType, Value: pointy.Float64(1)},
+			},
+		},
+		{
+			name: "Counter family without suffix",
+			text: "# TYPE requests counter\n" +
+				"requests_total 2.6\n",
+			want: []*metrics.Metric{
+				{ID: "requests", MType: metrics.CounterType, Delta: pointy.Int64(3)},
+			},
+		},
+		{
+			name: "Skip non-finite values",
+			text: "ratio NaN\nlimit +Inf\n",
+			want: nil,
+		},
+		{
+			name:    "Missing value",
+			text:    "up\n",
+			wantErr: true,
+		},
+		{
+			name:    "Invalid value",
+			text:    "up one\n",
+			wantErr: true,
+		},
+		{
+			name:    "Unterminated label value",
+			text:    `up{job="api} 1` + "\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(strings.NewReader(tt.text))
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidFormat)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParseEncoded(t *testing.T) {
+	collection := []*metrics.Metric{
+		metrics.NewCounter("PollCount", 3),
+		metrics.NewGauge("Alloc", 1.5),
+	}
+	collection[1].Labels = map[string]string{"host": "a"}
+
+	var buf bytes.Buffer
+	require.NoError(t, Encode(&buf, collection))
+
+	got, err := Parse(&buf)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, collection, got)
+}
@@ -1,6 +1,8 @@
 package proto
 
 import (
+	"strings"
+
 	"github.com/rs/zerolog/log"
 
 	"github.com/hikjik/go-metrics/internal/metrics"
@@ -13,27 +15,83 @@ func FromPb(pbMetric *Metric) *metrics.Metric {
 		metric = metrics.NewCounter(pbMetric.Id, pbMetric.Delta)
 	case Metric_GAUGE:
 		metric = metrics.NewGauge(pbMetric.Id, pbMetric.Value)
+	case Metric_HISTOGRAM:
+		metric = &metrics.Metric{
+			ID:    pbMetric.Id,
+			MType: metrics.HistogramType,
+		}
+		if h := pbMetric.Histogram; h != nil {
+			metric.Histogram = &metrics.Histogram{
+				Bounds: h.Bounds,
+				Counts: h.Counts,
+				Sum:    h.Sum,
+				Count:  h.Count,
+			}
+		}
 	default:
 		log.Warn().Msgf("Unknown metric type: %v", pbMetric.Type)
+		metric = &metrics.Metric{
+			ID:    pbMetric.Id,
+			MType: pbMetric.Type.String(),
+		}
 	}
 	metric.Hash = pbMetric.Hash
+	if len(pbMetric.Labels) > 0 {
+		metric.Labels = pbMetric.Labels
+	}
 	return metric
 }
 
 func ToPb(metric *metrics.Metric) *Metric {
 	pbMetric := Metric{
-		Id:   metric.ID,
-		Hash: metric.Hash,
+		Id:     metric.ID,
+		Hash:   metric.Hash,
+		Labels: metric.Labels,
 	}
 	switch metric.MType {
 	case metrics.CounterType:
 		pbMetric.Type = Metric_COUNTER
-		pbMetric.Delta = *metric.Delta
+		if metric.Delta != nil {
+			pbMetric.Delta = *metric.Delta
+		}
 	case metrics.GaugeType:
 		pbMetric.Type = Metric_GAUGE
-		pbMetric.Value = *metric.Value
+		if metric.Value != nil {
+			pbMetric.Value = *metric.Value
+		}
+	case metrics.HistogramType:
+		pbMetric.Type = Metric_HISTOGRAM
+		if h := metric.Histogram; h != nil {
+			pbMetric.Histogram = &Histogram{
+				Bounds: h.Bounds,
+				Counts: h.Counts,
+				Sum:    h.Sum,
+				Count:  h.Count,
+			}
+		}
 	default:
 		log.Warn().Msgf("Unknown metric type: %v", metric.MType)
 	}
 	return &pbMetric
 }
+
+func StatusFromPb(pbStatus *MetricStatus) metrics.Status {
+	status := metrics.Status{
+		ID:    pbStatus.Id,
+		MType: strings.ToLower(pbStatus.Type.String()),
+		Error: pbStatus.Error,
+	}
+	if len(pbStatus.Labels) > 0 {
+		status.Labels = pbStatus.Labels
+	}
+	return status
+}
+
+func StatusToPb(status metrics.Status) *MetricStatus {
+	return &MetricStatus{
+		Id:     status.ID,
+		Type:   Metric_Type(Metric_Type_value[strings.ToUpper(status.MType)]),
+		Labels: status.Labels,
+		Error:  status.Error,
+	}
+}
@@ -1,4 +1,8 @@
 // Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.2.0
+// - protoc             v3.14.0
+// source: internal/proto/metrics.proto
 
 package proto
 
@@ -21,6 +25,7 @@ type MetricsClient interface {
 	GetMetric(ctx context.Context, in *GetMetricRequest, opts ...grpc.CallOption) (*GetMetricResponse, error)
 	PutMetric(ctx context.Context, in *PutMetricRequest, opts ...grpc.CallOption) (*PutMetricResponse, error)
 	PutMetrics(ctx context.Context, opts ...grpc.CallOption) (Metrics_PutMetricsClient, error)
+	QueryRange(ctx context.Context, in *QueryRangeRequest, opts ...grpc.CallOption) (*QueryRangeResponse, error)
 }
 
 type metricsClient struct {
@@ -60,7 +65,7 @@ func (c *metricsClient) PutMetrics(ctx context.Context, opts ...grpc.CallOption)
 
 type Metrics_PutMetricsClient interface {
 	Send(*PutMetricRequest) error
-	CloseAndRecv() (*PutMetricResponse, error)
+	CloseAndRecv() (*PutMetricsResponse, error)
 	grpc.ClientStream
 }
 
@@ -72,17 +77,26 @@ func (x *metricsPutMetricsClient) Send(m *PutMetricRequest) error {
 	return x.ClientStream.SendMsg(m)
 }
 
-func (x *metricsPutMetricsClient) CloseAndRecv() (*PutMetricResponse, error) {
+func (x *metricsPutMetricsClient) CloseAndRecv() (*PutMetricsResponse, error) {
 	if err := x.ClientStream.CloseSend(); err != nil {
 		return nil, err
 	}
-	m := new(PutMetricResponse)
+	m := new(PutMetricsResponse)
 	if err := x.ClientStream.RecvMsg(m); err != nil {
 		return nil, err
 	}
 	return m, nil
 }
 
+func (c *metricsClient) QueryRange(ctx context.Context, in *QueryRangeRequest, opts ...grpc.CallOption) (*QueryRangeResponse, error) {
+	out := new(QueryRangeResponse)
+	err := c.cc.Invoke(ctx, "/proto.Metrics/QueryRange", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // MetricsServer is the server API for Metrics service.
 // All implementations must embed UnimplementedMetricsServer
 // for forward compatibility
@@ -90,6 +104,7 @@ type MetricsServer interface {
 	GetMetric(context.Context, *GetMetricRequest) (*GetMetricResponse, error)
 	PutMetric(context.Context, *PutMetricRequest) (*PutMetricResponse, error)
 	PutMetrics(Metrics_PutMetricsServer) error
+	QueryRange(context.Context, *QueryRangeRequest) (*QueryRangeResponse, error)
 	mustEmbedUnimplementedMetricsServer()
 }
 
@@ -106,6 +121,9 @@ func (UnimplementedMetricsServer) PutMetric(context.Context, *PutMetricRequest)
 func (UnimplementedMetricsServer) PutMetrics(Metrics_PutMetricsServer) error {
 	return status.Errorf(codes.Unimplemented, "method PutMetrics not implemented")
 }
+func (UnimplementedMetricsServer) QueryRange(context.Context, *QueryRangeRequest) (*QueryRangeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method QueryRange not implemented")
+}
 func (UnimplementedMetricsServer) mustEmbedUnimplementedMetricsServer() {}
 
 // UnsafeMetricsServer may be embedded to opt out of forward compatibility for this service.
@@ -160,7 +178,7 @@ func _Metrics_PutMetrics_Handler(srv interface{}, stream grpc.ServerStream) erro
 }
 
 type Metrics_PutMetricsServer interface {
-	SendAndClose(*PutMetricResponse) error
+	SendAndClose(*PutMetricsResponse) error
 	Recv() (*PutMetricRequest, error)
 	grpc.ServerStream
 }
@@ -169,7 +187,7 @@ type metricsPutMetricsServer struct {
 	grpc.ServerStream
 }
 
-func (x *metricsPutMetricsServer) SendAndClose(m *PutMetricResponse) error {
+func (x *metricsPutMetricsServer) SendAndClose(m *PutMetricsResponse) error {
 	return x.ServerStream.SendMsg(m)
 }
 
@@ -181,6 +199,24 @@ func (x *metricsPutMetricsServer) Recv() (*PutMetricRequest, error) {
 	return m, nil
 }
 
+func _Metrics_QueryRange_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(QueryRangeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MetricsServer).QueryRange(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.Metrics/QueryRange",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MetricsServer).QueryRange(ctx, req.(*QueryRangeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // Metrics_ServiceDesc is the grpc.ServiceDesc for Metrics service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -196,6 +232,10 @@ var Metrics_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "PutMetric",
 			Handler:    _Metrics_PutMetric_Handler,
 		},
+		{
+			MethodName: "QueryRange",
+			Handler:    _Metrics_QueryRange_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -0,0 +1,174 @@
+// Package query реализует выборку истории значений метрик
+// с агрегацией по интервалам фиксированной длины.
+package query
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/hikjik/go-metrics/internal/metrics"
+	"github.com/hikjik/go-metrics/internal/storage"
+)
+
+// Возможные функции агрегации значений внутри интервала
+const (
+	AggAvg  string = "avg"
+	AggMin  string = "min"
+	AggMax  string = "max"
+	AggSum  string = "sum"
+	AggLast string = "last"
+)
+
+// MaxPoints максимальное количество интервалов в ответе на запрос
+const MaxPoints = 11000
+
+// Значения параметров запроса по умолчанию
+const (
+	DefaultRange = time.Hour
+	DefaultStep  = time.Minute
+)
+
+// Request содержит параметры запроса истории значений метрики
+type Request struct {
+	From   time.Time
+	To     time.Time
+	Metric *metrics.Metric
+	Agg    string
+	Step   time.Duration
+}
+
+// Point содержит агрегированное значение метрики на интервале,
+// начинающемся в момент времени Timestamp
+type Point struct {
+	Timestamp time.Time `json:"timestamp"`
+	Value     float64   `json:"value"`
+}
+
+// Result содержит результат выполнения запроса
+type Result struct {
+	Labels map[string]string `json:"labels,omitempty"`
+	ID     string            `json:"id"`
+	MType  string            `json:"type"`
+	Points []Point           `json:"points"`
+}
+
+// Range выбирает из хранилища значения метрики в интервале [From, To]
+// и агрегирует их по интервалам длины Step. Интервалы без значений в ответ не попадают.
+// Для метрик типа CounterType агрегируются сохраненные приращения,
+// для метрик типа HistogramType - приращения количества наблюдаемых значений.
+func Range(ctx context.Context, store storage.Storage, r Request) (*Result, error) {
+	if err := validate(r); err != nil {
+		return nil, err
+	}
+
+	points, err := store.Range(ctx, r.Metric, r.From, r.To)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Result{
+		Labels: r.Metric.Labels,
+		ID:     r.Metric.ID,
+		MType:  r.Metric.MType,
+		Points: aggregate(points, r),
+	}, nil
+}
+
+func validate(r Request) error {
+	if r.Metric == nil || r.Metric.ID == "" || r.Step <= 0 || r.To.Before(r.From) {
+		return storage.ErrBadArgument
+	}
+	if r.To.Sub(r.From)/r.Step >= MaxPoints {
+		return storage.ErrBadArgument
+	}
+
+	switch r.Agg {
+	case AggAvg, AggMin, AggMax, AggSum, AggLast:
+		return nil
+	default:
+		return storage.ErrBadArgument
+	}
+}
+
+// bucket накапливает значения одного интервала
+type bucket struct {
+	sum   float64
+	min   float64
+	max   float64
+	last  float64
+	count int
+}
+
+func (b *bucket) add(value float64) {
+	if b.count == 0 {
+		b.min, b.max = value, value
+	}
+	b.sum += value
+	b.min = math.Min(b.min, value)
+	b.max = math.Max(b.max, value)
+	b.last = value
+	b.count++
+}
+
+func (b *bucket) result(agg string) float64 {
+	switch agg {
+	case AggMin:
+		return b.min
+	case AggMax:
+		return b.max
+	case AggSum:
+		return b.sum
+	case AggLast:
+		return b.last
+	default:
+		return b.sum / float64(b.count)
+	}
+}
+
+func aggregate(points []storage.Point, r Request) []Point {
+	result := make([]Point, 0)
+
+	var current bucket
+	var index int64 = -1
+	flush := func() {
+		if current.count == 0 {
+			return
+		}
+		result = append(result, Point{
+			Timestamp: r.From.Add(time.Duration(index) * r.Step),
+			Value:     current.result(r.Agg),
+		})
+		current = bucket{}
+	}
+
+	for _, point := range points {
+		value, ok := pointValue(point)
+		if !ok {
+			continue
+		}
+
+		i := int64(point.Timestamp.Sub(r.From) / r.Step)
+		if i != index {
+			flush()
+			index = i
+		}
+		current.add(value)
+	}
+	flush()
+
+	return result
+}
+
+func pointValue(point storage.Point) (float64, bool) {
+	switch {
+	case point.Value != nil:
+		return *point.Value, true
+	case point.Delta != nil:
+		return float64(*point.Delta), true
+	case point.Histogram != nil:
+		return float64(point.Histogram.Count), true
+	default:
+		return 0, false
+	}
+}
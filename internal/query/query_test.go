@@ -0,0 +1,115 @@
+package query
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/openlyinc/pointy"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hikjik/go-metrics/internal/metrics"
+	"github.com/hikjik/go-metrics/internal/storage"
+)
+
+type testStorage struct {
+	storage.Storage
+	points []storage.Point
+}
+
+func (s *testStorage) Range(_ context.Context, _ *metrics.Metric, from, to time.Time) ([]storage.Point, error) {
+	result := make([]storage.Point, 0)
+	for _, point := range s.points {
+		if !point.Timestamp.Before(from) && !point.Timestamp.After(to) {
+			result = append(result, point)
+		}
+	}
+	return result, nil
+}
+
+func TestRange(t *testing.T) {
+	from := time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC)
+	store := &testStorage{
+		points: []storage.Point{
+			{Timestamp: from.Add(time.Second * 10), Value: pointy.Float64(1)},
+			{Timestamp: from.Add(time.Second * 20), Value: pointy.Float64(3)},
+			{Timestamp: from.Add(time.Second * 70), Value: pointy.Float64(2)},
+			{Timestamp: from.Add(time.Second * 200), Value: pointy.Float64(10)},
+		},
+	}
+
+	tests := []struct {
+		err  error
+		name string
+		agg  string
+		want []float64
+	}{
+		{name: "Avg", agg: AggAvg, want: []float64{2, 2, 10}},
+		{name: "Min", agg: AggMin, want: []float64{1, 2, 10}},
+		{name: "Max", agg: AggMax, want: []float64{3, 2, 10}},
+		{name: "Sum", agg: AggSum, want: []float64{4, 2, 10}},
+		{name: "Last", agg: AggLast, want: []float64{3, 2, 10}},
+		{name: "Unknown aggregation", agg: "median", err: storage.ErrBadArgument},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Range(context.Background(), store, Request{
+				Metric: &metrics.Metric{ID: "Alloc", MType: metrics.GaugeType},
+				From:   from,
+				To:     from.Add(time.Hour),
+				Step:   time.Minute,
+				Agg:    tt.agg,
+			})
+			if tt.err != nil {
+				require.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, "Alloc", result.ID)
+			require.Equal(t, metrics.GaugeType, result.MType)
+
+			require.Len(t, result.Points, len(tt.want))
+			require.Equal(t, from, result.Points[0].Timestamp)
+			require.Equal(t, from.Add(time.Minute), result.Points[1].Timestamp)
+			require.Equal(t, from.Add(time.Minute*3), result.Points[2].Timestamp)
+			for i, value := range tt.want {
+				require.Equal(t, value, result.Points[i].Value)
+			}
+		})
+	}
+}
+
+func TestRangeBadRequest(t *testing.T) {
+	from := time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC)
+	metric := &metrics.Metric{ID: "Alloc", MType: metrics.GaugeType}
+
+	tests := []struct {
+		name    string
+		request Request
+	}{
+		{
+			name:    "Without metric",
+			request: Request{From: from, To: from.Add(time.Hour), Step: time.Minute, Agg: AggAvg},
+		},
+		{
+			name:    "Zero step",
+			request: Request{Metric: metric, From: from, To: from.Add(time.Hour), Agg: AggAvg},
+		},
+		{
+			name:    "Inverted interval",
+			request: Request{Metric: metric, From: from.Add(time.Hour), To: from, Step: time.Minute, Agg: AggAvg},
+		},
+		{
+			name:    "Too many points",
+			request: Request{Metric: metric, From: from, To: from.Add(time.Hour * 24), Step: time.Second, Agg: AggAvg},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Range(context.Background(), &testStorage{}, tt.request)
+			require.ErrorIs(t, err, storage.ErrBadArgument)
+		})
+	}
+}
@@ -0,0 +1,101 @@
+// Package remotewrite предназначен для приема метрик по протоколу Prometheus remote_write.
+package remotewrite
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/golang/snappy"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/hikjik/go-metrics/internal/metrics"
+	"github.com/hikjik/go-metrics/internal/proto/prompb"
+)
+
+// NameLabel метка, содержащая имя временного ряда
+const NameLabel = "__name__"
+
+// Ограничения размера запроса. Prometheus по умолчанию отправляет не более
+// нескольких тысяч сэмплов в запросе, что значительно меньше этих значений.
+const (
+	// MaxRequestSize максимальный размер сжатого тела запроса
+	MaxRequestSize = 16 << 20
+	// MaxDecodedSize максимальный размер распакованного сообщения WriteRequest
+	MaxDecodedSize = 64 << 20
+)
+
+// ErrInvalidRequest возвращается, если тело запроса не является корректным сообщением WriteRequest
+var ErrInvalidRequest = errors.New("invalid remote write request")
+
+// Decode распаковывает сжатое snappy тело запроса и разбирает сообщение WriteRequest.
+// Сообщения, размер которых после распаковки превышает MaxDecodedSize, отклоняются
+// до выделения памяти под них.
+func Decode(data []byte) (*prompb.WriteRequest, error) {
+	size, err := snappy.DecodedLen(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRequest, err)
+	}
+	if size > MaxDecodedSize {
+		return nil, fmt.Errorf("%w: decoded size %d exceeds limit %d", ErrInvalidRequest, size, MaxDecodedSize)
+	}
+
+	decoded, err := snappy.Decode(nil, data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRequest, err)
+	}
+
+	request := &prompb.WriteRequest{}
+	if err = proto.Unmarshal(decoded, request); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRequest, err)
+	}
+	return request, nil
+}
+
+// Metrics преобразует временные ряды запроса в метрики типа GaugeType.
+// Имя метрики берется из метки __name__, остальные метки становятся метками метрики.
+// Сэмплы ряда возвращаются в порядке возрастания времени, чтобы последним
+// было сохранено самое свежее значение. Значения NaN, в том числе stale-маркеры,
+// и бесконечные значения пропускаются: хранилище их не принимает.
+func Metrics(request *prompb.WriteRequest) ([]*metrics.Metric, error) {
+	var collection []*metrics.Metric
+	for _, series := range request.GetTimeseries() {
+		id, labels := splitLabels(series.GetLabels())
+		if id == "" {
+			return nil, fmt.Errorf("%w: series without %s label", ErrInvalidRequest, NameLabel)
+		}
+
+		samples := make([]*prompb.Sample, len(series.GetSamples()))
+		copy(samples, series.GetSamples())
+		sort.SliceStable(samples, func(i, j int) bool {
+			return samples[i].GetTimestamp() < samples[j].GetTimestamp()
+		})
+
+		for _, sample := range samples {
+			if math.IsNaN(sample.GetValue()) || math.IsInf(sample.GetValue(), 0) {
+				continue
+			}
+			m := metrics.NewGauge(id, sample.GetValue())
+			m.Labels = labels
+			collection = append(collection, m)
+		}
+	}
+	return collection, nil
+}
+
+func splitLabels(pairs []*prompb.Label) (string, map[string]string) {
+	var id string
+	var labels map[string]string
+	for _, pair := range pairs {
+		if pair.GetName() == NameLabel {
+			id = pair.GetValue()
+			continue
+		}
+		if labels == nil {
+			labels = make(map[string]string, len(pairs))
+		}
+		labels[pair.GetName()] = pair.GetValue()
+	}
+	return id, labels
+}
@@ -0,0 +1,106 @@
+package remotewrite
+
+import (
+	"math"
+	"testing"
+
+	"github.com/golang/snappy"
+	"github.com/openlyinc/pointy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/hikjik/go-metrics/internal/metrics"
+	"github.com/hikjik/go-metrics/internal/proto/prompb"
+)
+
+func TestDecode(t *testing.T) {
+	request := &prompb.WriteRequest{
+		Timeseries: []*prompb.TimeSeries{
+			{
+				Labels:  []*prompb.Label{{Name: NameLabel, Value: "up"}},
+				Samples: []*prompb.Sample{{Value: 1, Timestamp: 1000}},
+			},
+		},
+	}
+	data, err := proto.Marshal(request)
+	require.NoError(t, err)
+
+	decoded, err := Decode(snappy.Encode(nil, data))
+	require.NoError(t, err)
+	assert.True(t, proto.Equal(request, decoded))
+
+	_, err = Decode(data)
+	assert.ErrorIs(t, err, ErrInvalidRequest)
+
+	_, err = Decode(snappy.Encode(nil, []byte("invalid")))
+	assert.ErrorIs(t, err, ErrInvalidRequest)
+
+	// заголовок snappy заявляет размер распакованных данных около 4 ГиБ
+	_, err = Decode([]byte{0xff, 0xff, 0xff, 0xff, 0x0f, 0x00})
+	assert.ErrorIs(t, err, ErrInvalidRequest)
+}
+
+func TestMetrics(t *testing.T) {
+	tests := []struct {
+		name    string
+		series  []*prompb.TimeSeries
+		want    []*metrics.Metric
+		wantErr bool
+	}{
+		{
+			name: "Series with labels",
+			series: []*prompb.TimeSeries{
+				{
+					Labels: []*prompb.Label{
+						{Name: NameLabel, Value: "http_requests_total"},
+						{Name: "job", Value: "api"},
+					},
+					Samples: []*prompb.Sample{{Value: 3, Timestamp: 2000}, {Value: 1, Timestamp: 1000}},
+				},
+			},
+			want: []*metrics.Metric{
+				{ID: "http_requests_total", MType: metrics.GaugeType, Value: pointy.Float64(1), Labels: map[string]string{"job": "api"}},
+				{ID: "http_requests_total", MType: metrics.GaugeType, Value: pointy.Float64(3), Labels: map[string]string{"job": "api"}},
+			},
+		},
+		{
+			name: "Skip NaN and infinite samples",
+			series: []*prompb.TimeSeries{
+				{
+					Labels: []*prompb.Label{{Name: NameLabel, Value: "up"}},
+					Samples: []*prompb.Sample{
+						{Value: math.NaN(), Timestamp: 1000},
+						{Value: math.Inf(1), Timestamp: 1500},
+						{Value: 0, Timestamp: 2000},
+						{Value: math.Inf(-1), Timestamp: 2500},
+					},
+				},
+			},
+			want: []*metrics.Metric{
+				{ID: "up", MType: metrics.GaugeType, Value: pointy.Float64(0)},
+			},
+		},
+		{
+			name: "Series without name",
+			series: []*prompb.TimeSeries{
+				{
+					Labels:  []*prompb.Label{{Name: "job", Value: "api"}},
+					Samples: []*prompb.Sample{{Value: 1, Timestamp: 1000}},
+				},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Metrics(&prompb.WriteRequest{Timeseries: tt.series})
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidRequest)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -4,29 +4,35 @@ import (
 	"context"
 	"errors"
 	"io"
+	"time"
 
 	"github.com/rs/zerolog/log"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
+	"github.com/hikjik/go-metrics/internal/metrics"
 	pb "github.com/hikjik/go-metrics/internal/proto"
+	"github.com/hikjik/go-metrics/internal/query"
+	"github.com/hikjik/go-metrics/internal/service"
 	"github.com/hikjik/go-metrics/internal/storage"
 )
 
+var aggregations = map[pb.QueryRangeRequest_Aggregation]string{
+	pb.QueryRangeRequest_AVG:  query.AggAvg,
+	pb.QueryRangeRequest_MIN:  query.AggMin,
+	pb.QueryRangeRequest_MAX:  query.AggMax,
+	pb.QueryRangeRequest_SUM:  query.AggSum,
+	pb.QueryRangeRequest_LAST: query.AggLast,
+}
+
 func (s *Server) GetMetric(ctx context.Context, r *pb.GetMetricRequest) (*pb.GetMetricResponse, error) {
 	metric := pb.FromPb(r.GetMetric())
 
-	if err := s.Storage.Get(ctx, metric); err != nil {
+	if err := s.Service.Get(ctx, metric); err != nil {
 		return nil, handleStorageError(err)
 	}
 
-	if s.Signer != nil {
-		if err := s.Signer.Sign(metric); err != nil {
-			log.Warn().Err(err).Msg("Failed to set hash")
-			return nil, status.Error(codes.Internal, "Internal error: failed to set metric hash")
-		}
-	}
-
 	return &pb.GetMetricResponse{
 		Metric: pb.ToPb(metric),
 	}, nil
@@ -35,26 +41,19 @@ func (s *Server) GetMetric(ctx context.Context, r *pb.GetMetricRequest) (*pb.Get
 func (s *Server) PutMetric(ctx context.Context, r *pb.PutMetricRequest) (*pb.PutMetricResponse, error) {
 	metric := pb.FromPb(r.GetMetric())
 
-	if s.Signer != nil {
-		ok, err := s.Signer.Validate(metric)
-		if err != nil {
-			log.Warn().Err(err).Msg("Failed to validate hash")
-			return nil, status.Error(codes.Internal, "Failed to validate hash")
-		}
-		if !ok {
-			log.Info().Msgf("Invalid hash: %v", metric)
-			return nil, status.Error(codes.InvalidArgument, "Invalid hash")
-		}
-	}
-
-	if err := s.Storage.Put(ctx, metric); err != nil {
+	if err := s.Service.PutSigned(ctx, metric); err != nil {
 		return nil, handleStorageError(err)
 	}
 
 	return &pb.PutMetricResponse{}, nil
 }
 
+// maxStreamSize максимальное количество метрик в потоке PutMetrics.
+// Метрики потока сохраняются одним набором, поэтому накапливаются в памяти.
+const maxStreamSize = 10000
+
 func (s *Server) PutMetrics(stream pb.Metrics_PutMetricsServer) error {
+	var collection []*metrics.Metric
 	for {
 		message, err := stream.Recv()
 		if errors.Is(err, io.EOF) {
@@ -63,37 +62,81 @@ func (s *Server) PutMetrics(stream pb.Metrics_PutMetricsServer) error {
 		if err != nil {
 			return err
 		}
-
-		metric := pb.FromPb(message.GetMetric())
-		if s.Signer != nil {
-			var ok bool
-			ok, err = s.Signer.Validate(metric)
-			if err != nil {
-				log.Warn().Err(err).Msg("Failed to validate hash")
-				return status.Error(codes.Internal, "Failed to validate hash")
-			}
-			if !ok {
-				log.Info().Msgf("Invalid hash: %v", metric)
-				return status.Error(codes.InvalidArgument, "Invalid hash")
-			}
+		if len(collection) == maxStreamSize {
+			return status.Errorf(codes.InvalidArgument, "Too many metrics in stream, limit is %d", maxStreamSize)
 		}
+		collection = append(collection, pb.FromPb(message.GetMetric()))
+	}
+
+	statuses, err := s.Service.PutBatch(stream.Context(), collection)
+	if err != nil {
+		return handleStorageError(err)
+	}
 
-		if err = s.Storage.Put(stream.Context(), metric); err != nil {
-			return handleStorageError(err)
+	response := &pb.PutMetricsResponse{
+		Statuses: make([]*pb.MetricStatus, 0, len(statuses)),
+	}
+	for _, st := range statuses {
+		if !st.Accepted() {
+			log.Info().Msgf("Rejected metric %s of type %s: %s", st.ID, st.MType, st.Error)
 		}
+		response.Statuses = append(response.Statuses, pb.StatusToPb(st))
+	}
+	return stream.SendAndClose(response)
+}
+
+func (s *Server) QueryRange(ctx context.Context, r *pb.QueryRangeRequest) (*pb.QueryRangeResponse, error) {
+	if r.GetMetric() == nil {
+		return nil, status.Error(codes.InvalidArgument, "Invalid request args")
+	}
+
+	now := time.Now()
+	request := query.Request{
+		Metric: pb.FromPb(r.GetMetric()),
+		From:   now.Add(-query.DefaultRange),
+		To:     now,
+		Step:   query.DefaultStep,
+		Agg:    aggregations[r.GetAggregation()],
+	}
+	if r.GetFrom() != nil {
+		request.From = r.GetFrom().AsTime()
+	}
+	if r.GetTo() != nil {
+		request.To = r.GetTo().AsTime()
+	}
+	if r.GetStep() != nil {
+		request.Step = r.GetStep().AsDuration()
+	}
+
+	result, err := s.Service.Range(ctx, request)
+	if err != nil {
+		return nil, handleStorageError(err)
+	}
+
+	response := &pb.QueryRangeResponse{
+		Points: make([]*pb.Point, 0, len(result.Points)),
+	}
+	for _, point := range result.Points {
+		response.Points = append(response.Points, &pb.Point{
+			Timestamp: timestamppb.New(point.Timestamp),
+			Value:     point.Value,
+		})
 	}
-	return stream.SendAndClose(&pb.PutMetricResponse{})
+	return response, nil
 }
 
 func handleStorageError(err error) error {
-	switch err {
-	case storage.ErrUnknownMetricType:
+	switch {
+	case errors.Is(err, storage.ErrUnknownMetricType):
 		return status.Error(codes.Unimplemented, "Unknown metric type")
-	case storage.ErrBadArgument:
+	case errors.Is(err, storage.ErrBadArgument):
 		return status.Error(codes.InvalidArgument, "Invalid request args")
-	case storage.ErrNotFound:
+	case errors.Is(err, service.ErrInvalidHash):
+		return status.Error(codes.InvalidArgument, "Invalid hash")
+	case errors.Is(err, storage.ErrNotFound):
 		return status.Error(codes.NotFound, "Metric not found")
 	default:
+		log.Warn().Err(err).Msg("Failed to process request")
 		return status.Error(codes.Internal, "Internal storage error")
 	}
 }
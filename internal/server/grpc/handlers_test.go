@@ -0,0 +1,103 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/hikjik/go-metrics/internal/config"
+	"github.com/hikjik/go-metrics/internal/metrics"
+	pb "github.com/hikjik/go-metrics/internal/proto"
+	"github.com/hikjik/go-metrics/internal/service"
+)
+
+func TestQueryRange(t *testing.T) {
+	ctx := context.Background()
+	svc, err := service.New(ctx, config.ServerConfig{
+		StorageConfig: config.StorageConfig{
+			StoreFile:     t.TempDir() + "/storage.json",
+			StoreInterval: time.Second * 300,
+			HistorySize:   100,
+		},
+	})
+	require.NoError(t, err)
+	require.NoError(t, svc.Put(ctx, metrics.NewGauge("TestGauge", 1.0)))
+	require.NoError(t, svc.Put(ctx, metrics.NewGauge("TestGauge", 3.0)))
+	s := &Server{Service: svc}
+
+	now := time.Now()
+	tests := []struct {
+		request *pb.QueryRangeRequest
+		name    string
+		want    []float64
+		code    codes.Code
+	}{
+		{
+			name: "Query gauge max",
+			request: &pb.QueryRangeRequest{
+				Metric:      &pb.Metric{Id: "TestGauge", Type: pb.Metric_GAUGE},
+				Step:        durationpb.New(time.Hour),
+				Aggregation: pb.QueryRangeRequest_MAX,
+			},
+			want: []float64{3},
+		},
+		{
+			name: "Query gauge min",
+			request: &pb.QueryRangeRequest{
+				Metric:      &pb.Metric{Id: "TestGauge", Type: pb.Metric_GAUGE},
+				Step:        durationpb.New(time.Hour),
+				Aggregation: pb.QueryRangeRequest_MIN,
+			},
+			want: []float64{1},
+		},
+		{
+			name: "Query outside of range",
+			request: &pb.QueryRangeRequest{
+				Metric: &pb.Metric{Id: "TestGauge", Type: pb.Metric_GAUGE},
+				From:   timestamppb.New(now.Add(-2 * time.Hour)),
+				To:     timestamppb.New(now.Add(-time.Hour)),
+			},
+			want: []float64{},
+		},
+		{
+			name:    "Query unknown metric",
+			request: &pb.QueryRangeRequest{Metric: &pb.Metric{Id: "Unknown", Type: pb.Metric_GAUGE}},
+			want:    []float64{},
+		},
+		{
+			name:    "Query without metric",
+			request: &pb.QueryRangeRequest{},
+			code:    codes.InvalidArgument,
+		},
+		{
+			name: "Query invalid step",
+			request: &pb.QueryRangeRequest{
+				Metric: &pb.Metric{Id: "TestGauge", Type: pb.Metric_GAUGE},
+				Step:   durationpb.New(-time.Hour),
+			},
+			code: codes.InvalidArgument,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response, err := s.QueryRange(ctx, tt.request)
+			require.Equal(t, tt.code, status.Code(err))
+			if tt.code != codes.OK {
+				return
+			}
+			values := make([]float64, 0, len(response.GetPoints()))
+			for _, point := range response.GetPoints() {
+				values = append(values, point.GetValue())
+			}
+			assert.Equal(t, tt.want, values)
+		})
+	}
+}
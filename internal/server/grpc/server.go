@@ -4,39 +4,47 @@ package grpc
 
 import (
 	"context"
+	"crypto/tls"
 	"net"
 
 	"github.com/rs/zerolog/log"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
 
 	"github.com/hikjik/go-metrics/internal/config"
-	"github.com/hikjik/go-metrics/internal/metrics"
 	pb "github.com/hikjik/go-metrics/internal/proto"
-	"github.com/hikjik/go-metrics/internal/storage"
+	"github.com/hikjik/go-metrics/internal/service"
+	"github.com/hikjik/go-metrics/internal/tlsutil"
 )
 
 type Server struct {
 	pb.UnimplementedMetricsServer
 
-	Storage storage.Storage
-	Signer  metrics.Signer
-	Address string
+	Service   *service.Service
+	TLSConfig *tls.Config
+	Address   string
 }
 
 var _ pb.MetricsServer = (*Server)(nil)
 
-func NewServer(cfg config.ServerConfig) *Server {
-	store, err := storage.New(context.Background(), cfg.StorageConfig)
+// NewServer создает gRPC-сервер с адресом cfg.GRPCAddress и настройками TLS cfg.GRPCTLS
+func NewServer(cfg config.ServerConfig, svc *service.Service) *Server {
+	tlsConfig, err := tlsutil.ServerConfig(cfg.GRPCTLS)
 	if err != nil {
-		log.Fatal().Err(err).Msg("Failed to create storage")
+		log.Fatal().Err(err).Msg("Failed to setup grpc tls")
+	}
+	if tlsConfig == nil && cfg.EncryptionKeyPath != "" {
+		log.Warn().Msg("CRYPTO_KEY is not supported by grpc transport, use GRPC_TLS options instead")
 	}
-
-	signer := metrics.NewHMACSigner(cfg.SignatureKey)
 
 	return &Server{
-		Storage: store,
-		Signer:  signer,
-		Address: cfg.GRPCAddress,
+		Service:   svc,
+		TLSConfig: tlsConfig,
+		Address:   cfg.GRPCAddress,
 	}
 }
 
@@ -45,7 +53,14 @@ func (s *Server) Run(ctx context.Context) {
 	if err != nil {
 		log.Fatal().Err(err).Msg("Failed to start grpc server")
 	}
-	grpcServer := grpc.NewServer()
+	opts := []grpc.ServerOption{
+		grpc.UnaryInterceptor(s.filterIPUnary),
+		grpc.StreamInterceptor(s.filterIPStream),
+	}
+	if s.TLSConfig != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(s.TLSConfig)))
+	}
+	grpcServer := grpc.NewServer(opts...)
 	pb.RegisterMetricsServer(grpcServer, s)
 
 	go func() {
@@ -57,3 +72,33 @@ func (s *Server) Run(ctx context.Context) {
 		log.Error().Err(err).Msg("Error on grpc server Serve")
 	}
 }
+
+// filterIPUnary отклоняет запросы агентов, доступ с адреса которых запрещен
+func (s *Server) filterIPUnary(ctx context.Context, req interface{},
+	_ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if !s.allowed(ctx) {
+		return nil, status.Error(codes.PermissionDenied, "Untrusted agent address")
+	}
+	return handler(ctx, req)
+}
+
+// filterIPStream отклоняет потоки агентов, доступ с адреса которых запрещен
+func (s *Server) filterIPStream(srv interface{}, stream grpc.ServerStream,
+	_ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if !s.allowed(stream.Context()) {
+		return status.Error(codes.PermissionDenied, "Untrusted agent address")
+	}
+	return handler(srv, stream)
+}
+
+// allowed проверяет адрес агента по адресу соединения и метаданным x-real-ip и x-forwarded-for
+func (s *Server) allowed(ctx context.Context) bool {
+	var remoteAddr string
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		remoteAddr = p.Addr.String()
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	access := s.Service.ACL
+	return access.Allowed(access.ClientIP(remoteAddr, md.Get("x-real-ip"), md.Get("x-forwarded-for")))
+}
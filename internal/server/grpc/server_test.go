@@ -0,0 +1,100 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/hikjik/go-metrics/internal/acl"
+	"github.com/hikjik/go-metrics/internal/service"
+)
+
+type testStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *testStream) Context() context.Context {
+	return s.ctx
+}
+
+func TestFilterIP(t *testing.T) {
+	tests := []struct {
+		md         metadata.MD
+		name       string
+		remoteAddr string
+		code       codes.Code
+	}{
+		{name: "Allowed address", remoteAddr: "10.0.0.1:5000", code: codes.OK},
+		{name: "Denied address", remoteAddr: "192.168.0.1:5000", code: codes.PermissionDenied},
+		{
+			name:       "Spoofed x-real-ip",
+			remoteAddr: "192.168.0.1:5000",
+			md:         metadata.Pairs("x-real-ip", "10.0.0.1"),
+			code:       codes.PermissionDenied,
+		},
+		{
+			name:       "x-real-ip from proxy",
+			remoteAddr: "172.16.0.1:5000",
+			md:         metadata.Pairs("x-real-ip", "10.0.0.1"),
+			code:       codes.OK,
+		},
+		{
+			name:       "x-forwarded-for from proxy",
+			remoteAddr: "172.16.0.1:5000",
+			md:         metadata.Pairs("x-forwarded-for", "192.168.0.1, 10.0.0.1, 172.16.0.1"),
+			code:       codes.OK,
+		},
+		{
+			name:       "Denied x-forwarded-for from proxy",
+			remoteAddr: "172.16.0.1:5000",
+			md:         metadata.Pairs("x-forwarded-for", "10.0.0.1, 192.168.0.1"),
+			code:       codes.PermissionDenied,
+		},
+		{
+			name:       "Spoofed x-forwarded-for",
+			remoteAddr: "192.168.0.1:5000",
+			md:         metadata.Pairs("x-forwarded-for", "10.0.0.1"),
+			code:       codes.PermissionDenied,
+		},
+		{name: "Unknown address", code: codes.PermissionDenied},
+	}
+
+	access, err := acl.New([]string{"10.0.0.0/8"}, nil, []string{"172.16.0.1"})
+	require.NoError(t, err)
+	s := &Server{Service: &service.Service{ACL: access}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.remoteAddr != "" {
+				addr, err := net.ResolveTCPAddr("tcp", tt.remoteAddr)
+				require.NoError(t, err)
+				ctx = peer.NewContext(ctx, &peer.Peer{Addr: addr})
+			}
+			if tt.md != nil {
+				ctx = metadata.NewIncomingContext(ctx, tt.md)
+			}
+
+			_, err := s.filterIPUnary(ctx, nil, &grpc.UnaryServerInfo{},
+				func(context.Context, interface{}) (interface{}, error) {
+					return nil, nil
+				})
+			assert.Equal(t, tt.code, status.Code(err))
+
+			err = s.filterIPStream(nil, &testStream{ctx: ctx}, &grpc.StreamServerInfo{},
+				func(interface{}, grpc.ServerStream) error {
+					return nil
+				})
+			assert.Equal(t, tt.code, status.Code(err))
+		})
+	}
+}
@@ -1,19 +1,29 @@
 package http
 
 import (
+	"compress/gzip"
 	"embed"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"html/template"
 	"io"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/rs/zerolog/log"
 
+	"github.com/hikjik/go-metrics/internal/influx"
 	"github.com/hikjik/go-metrics/internal/metrics"
+	"github.com/hikjik/go-metrics/internal/prometheus"
+	"github.com/hikjik/go-metrics/internal/query"
+	"github.com/hikjik/go-metrics/internal/remotewrite"
+	"github.com/hikjik/go-metrics/internal/service"
 	"github.com/hikjik/go-metrics/internal/storage"
 )
 
@@ -23,14 +33,7 @@ var fs embed.FS
 // PingDatabase обработчик для проверки доступности базы данных
 func (s *Server) PingDatabase() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		db, ok := s.Storage.(*storage.DBStorage)
-		if !ok {
-			log.Warn().Msg("Failed to connect to db")
-			w.WriteHeader(http.StatusInternalServerError)
-			return
-		}
-
-		if err := db.Ping(r.Context()); err != nil {
+		if err := s.Service.Ping(r.Context()); err != nil {
 			log.Warn().Err(err).Msg("Failed to ping db")
 			w.WriteHeader(http.StatusInternalServerError)
 			return
@@ -52,7 +55,7 @@ func (s *Server) GetAllMetrics() http.HandlerFunc {
 			return
 		}
 
-		m, err := s.Storage.List(r.Context())
+		m, err := s.Service.List(r.Context())
 		if err != nil {
 			log.Warn().Err(err).Msg("Failed to list metrics")
 			w.WriteHeader(http.StatusInternalServerError)
@@ -68,16 +71,41 @@ func (s *Server) GetAllMetrics() http.HandlerFunc {
 	}
 }
 
+// GetPrometheusMetrics обработчик, возвращающий значения всех сохраненных метрик
+// в текстовом формате Prometheus
+func (s *Server) GetPrometheusMetrics() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		m, err := s.Service.List(r.Context())
+		if err != nil {
+			log.Warn().Err(err).Msg("Failed to list metrics")
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", prometheus.ContentType)
+		if err = prometheus.Encode(w, m); err != nil {
+			log.Warn().Err(err).Msg("Failed to encode metrics")
+		}
+	}
+}
+
 // GetMetric обработчик, возвращающий текущее значение запрашиваемой метрики в текстовом виде.
-// Параметры метрики передаются в URL параметрах запроса
+// Параметры метрики передаются в URL параметрах запроса, метки - в параметрах
+// строки запроса label вида name:value
 func (s *Server) GetMetric() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		labels, err := parseLabels(r.URL.Query())
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
 		m := &metrics.Metric{
-			ID:    chi.URLParam(r, "metricName"),
-			MType: chi.URLParam(r, "metricType"),
+			ID:     chi.URLParam(r, "metricName"),
+			MType:  chi.URLParam(r, "metricType"),
+			Labels: labels,
 		}
 
-		if err := s.Storage.Get(r.Context(), m); err != nil {
+		if err = s.Service.GetUnsigned(r.Context(), m); err != nil {
 			handleStorageError(w, err)
 			return
 		}
@@ -88,6 +116,14 @@ func (s *Server) GetMetric() http.HandlerFunc {
 			str = strconv.FormatFloat(*m.Value, 'f', -1, 64)
 		case metrics.CounterType:
 			str = fmt.Sprintf("%d", *m.Delta)
+		case metrics.HistogramType:
+			data, err := json.Marshal(m.Histogram)
+			if err != nil {
+				log.Warn().Err(err).Msg("Failed to encode histogram")
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			str = string(data)
 		}
 		w.WriteHeader(http.StatusOK)
 		if _, err := io.WriteString(w, str); err != nil {
@@ -120,19 +156,11 @@ func (s *Server) GetMetricJSON() http.HandlerFunc {
 			return
 		}
 
-		if err = s.Storage.Get(r.Context(), &m); err != nil {
+		if err = s.Service.Get(r.Context(), &m); err != nil {
 			handleStorageError(w, err)
 			return
 		}
 
-		if s.Signer != nil {
-			if err = s.Signer.Sign(&m); err != nil {
-				log.Warn().Err(err).Msg("Failed to set hash")
-				w.WriteHeader(http.StatusInternalServerError)
-				return
-			}
-		}
-
 		if err = json.NewEncoder(w).Encode(m); err != nil {
 			log.Warn().Err(err).Msg("Failed to encode metric")
 			w.WriteHeader(http.StatusInternalServerError)
@@ -141,8 +169,39 @@ func (s *Server) GetMetricJSON() http.HandlerFunc {
 	}
 }
 
+// QueryRange обработчик, возвращающий историю значений метрики, агрегированную по интервалам.
+// Параметры запроса id, type, from, to, step и agg передаются в URL запроса.
+// Моменты времени from и to задаются в формате RFC3339 или в секундах Unix time.
+// Метки временного ряда передаются в параметрах label в виде name:value.
+func (s *Server) QueryRange() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		request, err := parseQueryRange(r)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		result, err := s.Service.Range(r.Context(), request)
+		if err != nil {
+			handleStorageError(w, err)
+			return
+		}
+
+		if err = json.NewEncoder(w).Encode(result); err != nil {
+			log.Warn().Err(err).Msg("Failed to encode query result")
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
 // PutMetric обработчик принимает и сохраняет переданное значение метрики.
-// Параметры метрики передаются в URL параметрах запроса
+// Параметры метрики передаются в URL параметрах запроса.
+// Для метрик типа HistogramType передается одно наблюдаемое значение,
+// которое добавляется в гистограмму с границами интервалов сохраненной гистограммы
+// или metrics.DefaultBuckets для новой метрики.
 func (s *Server) PutMetric() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		metricValue := chi.URLParam(r, "metricValue")
@@ -165,12 +224,25 @@ func (s *Server) PutMetric() http.HandlerFunc {
 				return
 			}
 			m = metrics.NewCounter(metricName, delta)
+		case metrics.HistogramType:
+			value, err := strconv.ParseFloat(metricValue, 64)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			bounds, err := s.Service.HistogramBounds(r.Context(), metricName, nil)
+			if err != nil {
+				handleStorageError(w, err)
+				return
+			}
+			m = metrics.NewHistogram(metricName, bounds)
+			m.Histogram.Observe(value)
 		default:
 			w.WriteHeader(http.StatusNotImplemented)
 			return
 		}
 
-		if err := s.Storage.Put(r.Context(), m); err != nil {
+		if err := s.Service.Put(r.Context(), m); err != nil {
 			handleStorageError(w, err)
 			return
 		}
@@ -200,21 +272,7 @@ func (s *Server) PutMetricJSON() http.HandlerFunc {
 			return
 		}
 
-		if s.Signer != nil {
-			ok, err := s.Signer.Validate(&m)
-			if err != nil {
-				w.WriteHeader(http.StatusInternalServerError)
-				log.Warn().Err(err).Msg("Failed to validate hash")
-				return
-			}
-			if !ok {
-				w.WriteHeader(http.StatusBadRequest)
-				log.Info().Msgf("Invalid hash: %v", m)
-				return
-			}
-		}
-
-		if err := s.Storage.Put(r.Context(), &m); err != nil {
+		if err = s.Service.PutSigned(r.Context(), &m); err != nil {
 			handleStorageError(w, err)
 			return
 		}
@@ -238,42 +296,164 @@ func (s *Server) PutMetricBatchJSON() http.HandlerFunc {
 			return
 		}
 
-		var metricsBatch []metrics.Metric
+		var metricsBatch []*metrics.Metric
 		if err = json.Unmarshal(decryptedData, &metricsBatch); err != nil {
 			w.WriteHeader(http.StatusBadRequest)
 			return
 		}
-		for _, m := range metricsBatch {
-			if s.Signer != nil {
-				ok, err := s.Signer.Validate(&m)
-				if err != nil {
-					w.WriteHeader(http.StatusInternalServerError)
-					log.Warn().Err(err).Msg("Failed to validate hash")
-					return
+
+		statuses, err := s.Service.PutBatch(r.Context(), metricsBatch)
+		if err != nil {
+			handleStorageError(w, err)
+			return
+		}
+		for _, status := range statuses {
+			if !status.Accepted() {
+				log.Info().Msgf("Rejected metric %s of type %s: %s", status.ID, status.MType, status.Error)
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err = json.NewEncoder(w).Encode(statuses); err != nil {
+			log.Warn().Err(err).Msg("Failed to encode metric statuses")
+		}
+	}
+}
+
+// WriteInflux обработчик принимает и сохраняет метрики в формате InfluxDB line protocol.
+// Точность временных меток передается в параметре precision запроса, сами метки времени не учитываются.
+// Тело запроса может быть сжато gzip и зашифровано так же, как тело запросов агента.
+// Размер тела ограничен influx.MaxRequestSize, после распаковки - influx.MaxDecodedSize.
+// Если на сервере задан ключ подписи, заголовок HashSHA256 должен содержать
+// подпись расшифрованного тела запроса.
+func (s *Server) WriteInflux() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body := io.Reader(http.MaxBytesReader(w, r.Body, influx.MaxRequestSize))
+		if r.Header.Get("Content-Encoding") == "gzip" {
+			reader, err := gzip.NewReader(body)
+			if err != nil {
+				writeInfluxError(w, http.StatusBadRequest, "invalid gzip body")
+				return
+			}
+			defer func() {
+				if err = reader.Close(); err != nil {
+					log.Warn().Err(err).Msg("Failed to close gzip reader")
 				}
-				if !ok {
-					w.WriteHeader(http.StatusBadRequest)
-					log.Info().Msgf("Invalid hash: %v", m)
-					return
+			}()
+			body = io.LimitReader(reader, influx.MaxDecodedSize+1)
+		}
+
+		data, err := io.ReadAll(body)
+		if err != nil {
+			writeInfluxError(w, http.StatusBadRequest, "failed to read body")
+			return
+		}
+		if len(data) > influx.MaxDecodedSize {
+			writeInfluxError(w, http.StatusRequestEntityTooLarge, "decoded body is too large")
+			return
+		}
+		if data, err = s.Service.Decrypt(data); err != nil {
+			log.Warn().Err(err).Msg("Failed to decrypt request body")
+			writeInfluxError(w, http.StatusBadRequest, "failed to decrypt body")
+			return
+		}
+		if err = s.Service.ValidateBody(data, r.Header.Get("HashSHA256")); err != nil {
+			writeInfluxError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		points, err := influx.Parse(data, r.URL.Query().Get("precision"))
+		if err != nil {
+			writeInfluxError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		var rejected error
+		err = s.Influx.Write(points, func(collection []*metrics.Metric) error {
+			errs, putErr := s.Service.PutBatchUnsigned(r.Context(), collection)
+			if putErr != nil {
+				return putErr
+			}
+			for i, m := range collection {
+				if errs[i] != nil && rejected == nil {
+					rejected = fmt.Errorf("metric %s: %w", m.ID, errs[i])
 				}
 			}
+			return nil
+		})
+		if err != nil {
+			handleStorageError(w, err)
+			return
+		}
+		if rejected != nil {
+			writeInfluxError(w, http.StatusBadRequest, rejected.Error())
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
 
-			if err := s.Storage.Put(r.Context(), &m); err != nil {
-				handleStorageError(w, err)
+// WriteRemote обработчик принимает и сохраняет метрики, отправленные по протоколу
+// Prometheus remote_write. Тело запроса содержит сообщение WriteRequest, сжатое snappy.
+// Сэмплы сохраняются как метрики типа GaugeType одним набором: при сбое хранилища
+// не сохраняется ни один сэмпл запроса, и Prometheus может повторить его.
+// Если на сервере задан ключ подписи, заголовок HashSHA256 должен содержать
+// подпись тела запроса в том виде, в котором оно передается, то есть сжатого snappy.
+func (s *Server) WriteRemote() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, remotewrite.MaxRequestSize))
+		if err != nil {
+			http.Error(w, "failed to read body", http.StatusBadRequest)
+			return
+		}
+		if err = s.Service.ValidateBody(data, r.Header.Get("HashSHA256")); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		request, err := remotewrite.Decode(data)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		collection, err := remotewrite.Metrics(request)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		errs, err := s.Service.PutBatchUnsigned(r.Context(), collection)
+		if err != nil {
+			handleStorageError(w, err)
+			return
+		}
+		for i, m := range collection {
+			if errs[i] != nil {
+				http.Error(w, fmt.Sprintf("metric %s: %v", m.ID, errs[i]), http.StatusBadRequest)
 				return
 			}
 		}
-		w.WriteHeader(http.StatusOK)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// writeInfluxError возвращает ошибку в формате InfluxDB API
+func writeInfluxError(w http.ResponseWriter, statusCode int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	response := map[string]string{"code": "invalid", "message": message}
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Warn().Err(err).Msg("Failed to encode error")
 	}
 }
 
 func handleStorageError(w http.ResponseWriter, err error) {
-	switch err {
-	case storage.ErrUnknownMetricType:
+	switch {
+	case errors.Is(err, storage.ErrUnknownMetricType):
 		w.WriteHeader(http.StatusNotImplemented)
-	case storage.ErrBadArgument:
+	case errors.Is(err, storage.ErrBadArgument), errors.Is(err, service.ErrInvalidHash):
 		w.WriteHeader(http.StatusBadRequest)
-	case storage.ErrNotFound:
+	case errors.Is(err, storage.ErrNotFound):
 		w.WriteHeader(http.StatusNotFound)
 	default:
 		log.Warn().Err(err).Msg("Failed to put metric")
@@ -281,15 +461,88 @@ func handleStorageError(w http.ResponseWriter, err error) {
 	}
 }
 
+func parseQueryRange(r *http.Request) (query.Request, error) {
+	params := r.URL.Query()
+
+	now := time.Now()
+	request := query.Request{
+		Metric: &metrics.Metric{
+			ID:    params.Get("id"),
+			MType: params.Get("type"),
+		},
+		From: now.Add(-query.DefaultRange),
+		To:   now,
+		Step: query.DefaultStep,
+		Agg:  query.AggAvg,
+	}
+
+	var err error
+	if request.Metric.Labels, err = parseLabels(params); err != nil {
+		return request, err
+	}
+	if value := params.Get("from"); value != "" {
+		if request.From, err = parseTime(value); err != nil {
+			return request, err
+		}
+	}
+	if value := params.Get("to"); value != "" {
+		if request.To, err = parseTime(value); err != nil {
+			return request, err
+		}
+	}
+	if value := params.Get("step"); value != "" {
+		if request.Step, err = parseDuration(value); err != nil {
+			return request, err
+		}
+	}
+	if value := params.Get("agg"); value != "" {
+		request.Agg = value
+	}
+	return request, nil
+}
+
+// parseLabels возвращает метки временного ряда, переданные в параметрах
+// строки запроса label вида name:value. Остальные параметры не учитываются.
+func parseLabels(params url.Values) (map[string]string, error) {
+	var labels map[string]string
+	for _, label := range params["label"] {
+		name, value, ok := cut(label, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid label: %s", label)
+		}
+		if labels == nil {
+			labels = make(map[string]string)
+		}
+		labels[name] = value
+	}
+	return labels, nil
+}
+
+func cut(s, sep string) (string, string, bool) {
+	if i := strings.Index(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return s, "", false
+}
+
+func parseTime(value string) (time.Time, error) {
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+		return time.Unix(0, int64(seconds*float64(time.Second))), nil
+	}
+	return time.Parse(time.RFC3339Nano, value)
+}
+
+func parseDuration(value string) (time.Duration, error) {
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+		return time.Duration(seconds * float64(time.Second)), nil
+	}
+	return time.ParseDuration(value)
+}
+
 func (s *Server) decryptRequestBody(r *http.Request) ([]byte, error) {
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
 		return nil, err
 	}
-
-	if s.Decrypter == nil {
-		return body, nil
-	}
-
-	return s.Decrypter.Decrypt(body)
+	return s.Service.Decrypt(body)
 }
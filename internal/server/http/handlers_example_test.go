@@ -70,7 +70,7 @@ func ExampleServer_GetMetricJSON() {
 	srv := httptest.NewServer(server.Route())
 
 	metric := metrics.NewGauge("SomeMetric", 1.0)
-	if err := server.Storage.Put(context.Background(), metric); err != nil {
+	if err := server.Service.Storage.Put(context.Background(), metric); err != nil {
 		log.Fatal().Err(err)
 	}
 	var buf bytes.Buffer
@@ -2,7 +2,11 @@ package http
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"io/ioutil"
 	"net/http"
@@ -11,11 +15,19 @@ import (
 	"testing"
 	"time"
 
+	"github.com/golang/snappy"
+	"github.com/rs/zerolog/log"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
 
 	"github.com/hikjik/go-metrics/internal/config"
+	"github.com/hikjik/go-metrics/internal/influx"
 	"github.com/hikjik/go-metrics/internal/metrics"
+	"github.com/hikjik/go-metrics/internal/proto/prompb"
+	"github.com/hikjik/go-metrics/internal/query"
+	"github.com/hikjik/go-metrics/internal/remotewrite"
+	"github.com/hikjik/go-metrics/internal/service"
 )
 
 func NewTestServer() *Server {
@@ -24,10 +36,15 @@ func NewTestServer() *Server {
 			StoreFile:     "tmp/storage.json",
 			StoreInterval: time.Second * 300,
 			Restore:       false,
+			HistorySize:   100,
 		},
 	}
 
-	return NewServer(cfg)
+	svc, err := service.New(context.Background(), cfg)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to create service")
+	}
+	return NewServer(cfg, svc)
 }
 
 func TestPutGetHandler(t *testing.T) {
@@ -53,6 +70,24 @@ func TestPutGetHandler(t *testing.T) {
 			method: http.MethodGet,
 			want:   want{statusCode: http.StatusOK, body: "123.45"},
 		},
+		{
+			name:   "Get gauge metric with unrelated query parameter",
+			target: "/value/gauge/TestGauge?_=1656633600",
+			method: http.MethodGet,
+			want:   want{statusCode: http.StatusOK, body: "123.45"},
+		},
+		{
+			name:   "Get gauge metric with unknown label",
+			target: "/value/gauge/TestGauge?label=host:localhost",
+			method: http.MethodGet,
+			want:   want{statusCode: http.StatusNotFound},
+		},
+		{
+			name:   "Get gauge metric with invalid label",
+			target: "/value/gauge/TestGauge?label=host",
+			method: http.MethodGet,
+			want:   want{statusCode: http.StatusBadRequest},
+		},
 		{
 			name:   "Put counter metric ok",
 			target: "/update/counter/TestCounter/123",
@@ -65,6 +100,28 @@ func TestPutGetHandler(t *testing.T) {
 			method: http.MethodGet,
 			want:   want{statusCode: http.StatusOK, body: "123"},
 		},
+		{
+			name:   "Put histogram metric ok",
+			target: "/update/histogram/TestHistogram/0.3",
+			method: http.MethodPost,
+			want:   want{statusCode: http.StatusOK},
+		},
+		{
+			name:   "Get histogram metric ok",
+			target: "/value/histogram/TestHistogram",
+			method: http.MethodGet,
+			want: want{
+				statusCode: http.StatusOK,
+				body: `{"bounds":[0.005,0.01,0.025,0.05,0.1,0.25,0.5,1,2.5,5,10],` +
+					`"counts":[0,0,0,0,0,0,1,0,0,0,0,0],"sum":0.3,"count":1}`,
+			},
+		},
+		{
+			name:   "Put histogram metric invalid value",
+			target: "/update/histogram/TestHistogram/none",
+			method: http.MethodPost,
+			want:   want{statusCode: http.StatusBadRequest},
+		},
 		{
 			name:   "Get unknown metric type",
 			target: "/value/unknown/TestGauge",
@@ -149,6 +206,92 @@ func TestGetAllHandler(t *testing.T) {
 	})
 }
 
+func TestPrometheusHandler(t *testing.T) {
+	t.Run("Get metrics in prometheus format", func(t *testing.T) {
+		server := NewTestServer()
+		require.NoError(t, server.Service.Storage.Put(context.Background(), metrics.NewGauge("TestGauge", 1.5)))
+		require.NoError(t, server.Service.Storage.Put(context.Background(), metrics.NewCounter("TestCounter", 2)))
+
+		request := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		w := httptest.NewRecorder()
+		server.Route().ServeHTTP(w, request)
+
+		response := w.Result()
+		body, err := ioutil.ReadAll(response.Body)
+		require.NoError(t, err)
+		require.NoError(t, response.Body.Close())
+
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+		assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", response.Header.Get("Content-Type"))
+		assert.Contains(t, string(body), "# TYPE TestGauge gauge\nTestGauge 1.5\n")
+		assert.Contains(t, string(body), "# TYPE TestCounter_total counter\nTestCounter_total 2\n")
+	})
+}
+
+func TestQueryRangeHandler(t *testing.T) {
+	server := NewTestServer()
+	require.NoError(t, server.Service.Storage.Put(context.Background(), metrics.NewGauge("TestGauge", 1.0)))
+	require.NoError(t, server.Service.Storage.Put(context.Background(), metrics.NewGauge("TestGauge", 3.0)))
+
+	tests := []struct {
+		name       string
+		target     string
+		points     int
+		statusCode int
+	}{
+		{
+			name:       "Query gauge ok",
+			target:     "/api/v1/query_range?id=TestGauge&type=gauge&step=1h&agg=max",
+			statusCode: http.StatusOK,
+			points:     1,
+		},
+		{
+			name:       "Query unknown metric",
+			target:     "/api/v1/query_range?id=Unknown&type=gauge",
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Query unknown metric type",
+			target:     "/api/v1/query_range?id=TestGauge&type=unknown",
+			statusCode: http.StatusNotImplemented,
+		},
+		{
+			name:       "Query invalid step",
+			target:     "/api/v1/query_range?id=TestGauge&type=gauge&step=none",
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "Query unknown aggregation",
+			target:     "/api/v1/query_range?id=TestGauge&type=gauge&agg=none",
+			statusCode: http.StatusBadRequest,
+		},
+	}
+
+	router := server.Route()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, request)
+
+			response := w.Result()
+			defer func() {
+				require.NoError(t, response.Body.Close())
+			}()
+			require.Equal(t, tt.statusCode, response.StatusCode)
+
+			if response.StatusCode == http.StatusOK {
+				var result query.Result
+				require.NoError(t, json.NewDecoder(response.Body).Decode(&result))
+				require.Len(t, result.Points, tt.points)
+				if tt.points > 0 {
+					assert.Equal(t, 3.0, result.Points[0].Value)
+				}
+			}
+		})
+	}
+}
+
 func TestPutGetJSONHandler(t *testing.T) {
 	type want struct {
 		body        string
@@ -198,6 +341,31 @@ func TestPutGetJSONHandler(t *testing.T) {
 				body:        `{"id":"TestGauge","type":"gauge","value":123.45}`,
 			},
 		},
+		{
+			name:        "PutJSON gauge metric with labels ok",
+			target:      "/update/",
+			contentType: "application/json",
+			body:        `{"id":"TestGauge","type":"gauge","value":1.5,"labels":{"host":"localhost"}}`,
+			want:        want{statusCode: http.StatusOK},
+		},
+		{
+			name:        "GetJSON gauge metric with labels ok",
+			target:      "/value/",
+			contentType: "application/json",
+			body:        `{"id":"TestGauge","type":"gauge","labels":{"host":"localhost"}}`,
+			want: want{
+				statusCode:  http.StatusOK,
+				contentType: "application/json",
+				body:        `{"id":"TestGauge","type":"gauge","value":1.5,"labels":{"host":"localhost"}}`,
+			},
+		},
+		{
+			name:        "PutJSON gauge metric invalid labels",
+			target:      "/update/",
+			contentType: "application/json",
+			body:        `{"id":"TestGauge","type":"gauge","value":1.5,"labels":{"host name":"localhost"}}`,
+			want:        want{statusCode: http.StatusBadRequest},
+		},
 		{
 			name:        "GetJSON bad content type",
 			target:      "/value/",
@@ -270,6 +438,256 @@ func TestPutGetJSONHandler(t *testing.T) {
 	}
 }
 
+func TestPutBatchJSONHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		want       string
+		statusCode int
+	}{
+		{
+			name:       "Batch ok",
+			body:       `[{"id":"TestCounter","type":"counter","delta":1},{"id":"TestGauge","type":"gauge","value":1.5}]`,
+			want:       `[{"id":"TestCounter","type":"counter"},{"id":"TestGauge","type":"gauge"}]`,
+			statusCode: http.StatusOK,
+		},
+		{
+			name: "Batch with rejected metrics",
+			body: `[{"id":"TestGauge","type":"unknown","value":0},` +
+				`{"id":"TestGauge","type":"gauge","value":1.5,"labels":{"host name":"localhost"}},` +
+				`{"id":"TestCounter","type":"counter","delta":1}]`,
+			want: `[{"id":"TestGauge","type":"unknown","error":"unknown metric type"},` +
+				`{"id":"TestGauge","type":"gauge","labels":{"host name":"localhost"},"error":"bad argument"},` +
+				`{"id":"TestCounter","type":"counter"}]`,
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Batch invalid body",
+			body:       `[{"id":`,
+			statusCode: http.StatusBadRequest,
+		},
+	}
+
+	router := NewTestServer().Route()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodPost, "/updates/", strings.NewReader(tt.body))
+			request.Header.Add("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, request)
+			response := w.Result()
+
+			body, err := ioutil.ReadAll(response.Body)
+			require.NoError(t, err)
+			require.Equal(t, tt.statusCode, response.StatusCode)
+			if tt.want != "" {
+				assert.JSONEq(t, tt.want, string(body))
+			}
+			require.NoError(t, response.Body.Close())
+		})
+	}
+}
+
+func TestWriteInfluxHandler(t *testing.T) {
+	var gzipped bytes.Buffer
+	zw := gzip.NewWriter(&gzipped)
+	_, err := zw.Write([]byte("mem,host=server01 used=2.5 1465839830\n"))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+
+	var bomb bytes.Buffer
+	zw = gzip.NewWriter(&bomb)
+	_, err = zw.Write(make([]byte, influx.MaxDecodedSize+1))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+
+	tests := []struct {
+		name       string
+		target     string
+		encoding   string
+		body       []byte
+		statusCode int
+	}{
+		{
+			name:       "Write points",
+			target:     "/api/v2/write?precision=ns",
+			body:       []byte("cpu,host=server01 usage=1.5,count=3i 1465839830100400200\n"),
+			statusCode: http.StatusNoContent,
+		},
+		{
+			name:       "Write gzip body",
+			target:     "/api/v2/write?precision=s",
+			encoding:   "gzip",
+			body:       gzipped.Bytes(),
+			statusCode: http.StatusNoContent,
+		},
+		{
+			name:       "Invalid line",
+			target:     "/api/v2/write",
+			body:       []byte("cpu usage"),
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "Invalid precision",
+			target:     "/api/v2/write?precision=h",
+			body:       []byte("cpu usage=1"),
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "Body too large",
+			target:     "/api/v2/write",
+			body:       make([]byte, influx.MaxRequestSize+1),
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "Decoded body too large",
+			target:     "/api/v2/write",
+			encoding:   "gzip",
+			body:       bomb.Bytes(),
+			statusCode: http.StatusRequestEntityTooLarge,
+		},
+	}
+
+	server := NewTestServer()
+	router := server.Route()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodPost, tt.target, bytes.NewReader(tt.body))
+			if tt.encoding != "" {
+				request.Header.Set("Content-Encoding", tt.encoding)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, request)
+			require.Equal(t, tt.statusCode, w.Code)
+		})
+	}
+
+	labels := map[string]string{"host": "server01"}
+	usage := &metrics.Metric{ID: "cpu_usage", MType: metrics.GaugeType, Labels: labels}
+	require.NoError(t, server.Service.Storage.Get(context.Background(), usage))
+	assert.Equal(t, 1.5, *usage.Value)
+
+	used := &metrics.Metric{ID: "mem_used", MType: metrics.GaugeType, Labels: labels}
+	require.NoError(t, server.Service.Storage.Get(context.Background(), used))
+	assert.Equal(t, 2.5, *used.Value)
+}
+
+func TestWriteRemoteHandler(t *testing.T) {
+	request := &prompb.WriteRequest{
+		Timeseries: []*prompb.TimeSeries{
+			{
+				Labels: []*prompb.Label{
+					{Name: remotewrite.NameLabel, Value: "node_load1"},
+					{Name: "instance", Value: "localhost:9100"},
+				},
+				Samples: []*prompb.Sample{{Value: 0.5, Timestamp: 2000}, {Value: 0.25, Timestamp: 1000}},
+			},
+		},
+	}
+	data, err := proto.Marshal(request)
+	require.NoError(t, err)
+
+	noName, err := proto.Marshal(&prompb.WriteRequest{
+		Timeseries: []*prompb.TimeSeries{
+			{Samples: []*prompb.Sample{{Value: 1, Timestamp: 1000}}},
+		},
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		body       []byte
+		statusCode int
+	}{
+		{
+			name:       "Write samples",
+			body:       snappy.Encode(nil, data),
+			statusCode: http.StatusNoContent,
+		},
+		{
+			name:       "Uncompressed body",
+			body:       data,
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "Series without name",
+			body:       snappy.Encode(nil, noName),
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "Body too large",
+			body:       make([]byte, remotewrite.MaxRequestSize+1),
+			statusCode: http.StatusBadRequest,
+		},
+	}
+
+	server := NewTestServer()
+	router := server.Route()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodPost, "/api/v1/write", bytes.NewReader(tt.body))
+			request.Header.Set("Content-Encoding", "snappy")
+			request.Header.Set("Content-Type", "application/x-protobuf")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, request)
+			require.Equal(t, tt.statusCode, w.Code)
+		})
+	}
+
+	load := &metrics.Metric{
+		ID:     "node_load1",
+		MType:  metrics.GaugeType,
+		Labels: map[string]string{"instance": "localhost:9100"},
+	}
+	require.NoError(t, server.Service.Storage.Get(context.Background(), load))
+	assert.Equal(t, 0.5, *load.Value)
+}
+
+func TestWriteRemoteHandlerSignature(t *testing.T) {
+	data, err := proto.Marshal(&prompb.WriteRequest{
+		Timeseries: []*prompb.TimeSeries{
+			{
+				Labels:  []*prompb.Label{{Name: remotewrite.NameLabel, Value: "up"}},
+				Samples: []*prompb.Sample{{Value: 1, Timestamp: 1000}},
+			},
+		},
+	})
+	require.NoError(t, err)
+	body := snappy.Encode(nil, data)
+
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write(body)
+	hash := hex.EncodeToString(mac.Sum(nil))
+
+	cfg := config.ServerConfig{
+		SignatureKey:  "secret",
+		StorageConfig: config.StorageConfig{StoreInterval: time.Second * 300},
+	}
+	svc, err := service.New(context.Background(), cfg)
+	require.NoError(t, err)
+	router := NewServer(cfg, svc).Route()
+
+	for _, tt := range []struct {
+		name       string
+		hash       string
+		statusCode int
+	}{
+		{name: "Unsigned body", statusCode: http.StatusBadRequest},
+		{name: "Invalid signature", hash: strings.Repeat("0", len(hash)), statusCode: http.StatusBadRequest},
+		{name: "Signed body", hash: hash, statusCode: http.StatusNoContent},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodPost, "/api/v1/write", bytes.NewReader(body))
+			if tt.hash != "" {
+				request.Header.Set("HashSHA256", tt.hash)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, request)
+			require.Equal(t, tt.statusCode, w.Code)
+		})
+	}
+}
+
 func BenchmarkServer_PutMetricJSON(b *testing.B) {
 	router := NewTestServer().Route()
 	srv := httptest.NewServer(router)
@@ -338,7 +756,7 @@ func BenchmarkServer_GetMetricJSON(b *testing.B) {
 	defer srv.Close()
 
 	metric := metrics.NewGauge("SomeMetric", 1.0)
-	err := server.Storage.Put(context.Background(), metric)
+	err := server.Service.Storage.Put(context.Background(), metric)
 	require.NoError(b, err)
 
 	b.ReportAllocs()
@@ -371,7 +789,7 @@ func BenchmarkServer_GetMetricJSONParallel(b *testing.B) {
 		defer srv.Close()
 
 		metric := metrics.NewGauge("SomeMetric", 1.0)
-		err := server.Storage.Put(context.Background(), metric)
+		err := server.Service.Storage.Put(context.Background(), metric)
 		require.NoError(b, err)
 
 		b.ReportAllocs()
@@ -1,26 +1,19 @@
 package http
 
 import (
-	"net"
 	"net/http"
 
-	"github.com/rs/zerolog/log"
+	"github.com/hikjik/go-metrics/internal/acl"
 )
 
-func FilterIP(trustedSubnet string) func(http.Handler) http.Handler {
+// FilterIP отклоняет запросы агентов, доступ с адреса которых запрещен правилами access
+func FilterIP(access *acl.ACL) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			if trustedSubnet != "" {
-				_, ipNet, err := net.ParseCIDR(trustedSubnet)
-				if err != nil {
-					log.Warn().Err(err).Msg("Failed to parse CIDR")
-					w.WriteHeader(http.StatusInternalServerError)
-					return
-				}
-				if !ipNet.Contains(net.ParseIP(r.RemoteAddr)) {
-					w.WriteHeader(http.StatusForbidden)
-					return
-				}
+			ip := access.ClientIP(r.RemoteAddr, r.Header.Values("X-Real-IP"), r.Header.Values("X-Forwarded-For"))
+			if !access.Allowed(ip) {
+				w.WriteHeader(http.StatusForbidden)
+				return
 			}
 			next.ServeHTTP(w, r)
 		})
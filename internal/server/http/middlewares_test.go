@@ -0,0 +1,45 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hikjik/go-metrics/internal/acl"
+)
+
+func TestFilterIP(t *testing.T) {
+	tests := []struct {
+		name       string
+		remoteAddr string
+		realIP     string
+		statusCode int
+	}{
+		{name: "Allowed address", remoteAddr: "10.0.0.1:5000", statusCode: http.StatusOK},
+		{name: "Denied address", remoteAddr: "192.168.0.1:5000", statusCode: http.StatusForbidden},
+		{name: "Spoofed X-Real-IP", remoteAddr: "192.168.0.1:5000", realIP: "10.0.0.1", statusCode: http.StatusForbidden},
+		{name: "X-Real-IP from proxy", remoteAddr: "172.16.0.1:5000", realIP: "10.0.0.1", statusCode: http.StatusOK},
+	}
+
+	access, err := acl.New([]string{"10.0.0.0/8"}, nil, []string{"172.16.0.1"})
+	require.NoError(t, err)
+	handler := FilterIP(access)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, "/", nil)
+			request.RemoteAddr = tt.remoteAddr
+			if tt.realIP != "" {
+				request.Header.Set("X-Real-IP", tt.realIP)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, request)
+			assert.Equal(t, tt.statusCode, w.Code)
+		})
+	}
+}
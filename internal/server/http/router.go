@@ -9,15 +9,18 @@ import (
 func (s *Server) Route() *chi.Mux {
 	router := chi.NewRouter()
 	router.Use(middleware.Compress(5))
-	router.Use(middleware.RealIP)
-	router.Use(FilterIP(s.TrustedSubnet))
+	router.Use(FilterIP(s.Service.ACL))
 	router.Mount("/debug", middleware.Profiler())
 	router.Get("/ping", s.PingDatabase())
 	router.Get("/", s.GetAllMetrics())
+	router.Get("/metrics", s.GetPrometheusMetrics())
 	router.Get("/value/{metricType}/{metricName}", s.GetMetric())
 	router.Post("/update/{metricType}/{metricName}/{metricValue}", s.PutMetric())
 	router.Post("/update/", s.PutMetricJSON())
 	router.Post("/updates/", s.PutMetricBatchJSON())
 	router.Post("/value/", s.GetMetricJSON())
+	router.Post("/api/v2/write", s.WriteInflux())
+	router.Post("/api/v1/write", s.WriteRemote())
+	router.Get("/api/v1/query_range", s.QueryRange())
 	return router
 }
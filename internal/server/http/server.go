@@ -4,52 +4,50 @@ package http
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"net/http"
 
 	"github.com/rs/zerolog/log"
 
 	"github.com/hikjik/go-metrics/internal/config"
-	"github.com/hikjik/go-metrics/internal/encryption"
-	"github.com/hikjik/go-metrics/internal/encryption/rsa"
-	"github.com/hikjik/go-metrics/internal/metrics"
-	"github.com/hikjik/go-metrics/internal/storage"
+	"github.com/hikjik/go-metrics/internal/influx"
+	"github.com/hikjik/go-metrics/internal/service"
+	"github.com/hikjik/go-metrics/internal/tlsutil"
 )
 
 type Server struct {
-	Storage       storage.Storage
-	Signer        metrics.Signer
-	Decrypter     encryption.Decrypter
-	TrustedSubnet string
-	Address       string
+	Service   *service.Service
+	TLSConfig *tls.Config
+	Influx    *influx.Converter
+	Address   string
 }
 
-func NewServer(cfg config.ServerConfig) *Server {
-	store, err := storage.New(context.Background(), cfg.StorageConfig)
+// NewServer создает HTTP-сервер с адресом cfg.Address и настройками TLS cfg.HTTPTLS
+func NewServer(cfg config.ServerConfig, svc *service.Service) *Server {
+	tlsConfig, err := tlsutil.ServerConfig(cfg.HTTPTLS)
 	if err != nil {
-		log.Fatal().Err(err).Msg("Failed to create storage")
+		log.Fatal().Err(err).Msg("Failed to setup http tls")
 	}
 
-	decrypter, err := rsa.NewDecrypter(cfg.EncryptionKeyPath)
+	converter, err := influx.NewConverter(cfg.InfluxCounters)
 	if err != nil {
-		log.Fatal().Err(err).Msg("Failed to setup rsa decryption")
+		log.Fatal().Err(err).Msg("Failed to setup influx converter")
 	}
 
-	signer := metrics.NewHMACSigner(cfg.SignatureKey)
-
 	return &Server{
-		Storage:       store,
-		Signer:        signer,
-		Decrypter:     decrypter,
-		TrustedSubnet: cfg.TrustedSubnet,
-		Address:       cfg.Address,
+		Service:   svc,
+		TLSConfig: tlsConfig,
+		Influx:    converter,
+		Address:   cfg.Address,
 	}
 }
 
 func (s *Server) Run(ctx context.Context) {
 	srv := &http.Server{
-		Addr:    s.Address,
-		Handler: s.Route(),
+		Addr:      s.Address,
+		Handler:   s.Route(),
+		TLSConfig: s.TLSConfig,
 	}
 
 	go func() {
@@ -58,7 +56,15 @@ func (s *Server) Run(ctx context.Context) {
 			log.Error().Err(err).Msg("Failed to shutdown HTTP server")
 		}
 	}()
-	if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+
+	var err error
+	if s.TLSConfig != nil {
+		// сертификат сервера задан в TLSConfig
+		err = srv.ListenAndServeTLS("", "")
+	} else {
+		err = srv.ListenAndServe()
+	}
+	if !errors.Is(err, http.ErrServerClosed) {
 		log.Error().Err(err).Msg("Error on http server ListenAndServe")
 	}
 }
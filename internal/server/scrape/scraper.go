@@ -0,0 +1,135 @@
+// Package scrape содержит реализацию периодического сбора метрик
+// с целей, публикующих их в текстовом формате Prometheus.
+package scrape
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/hikjik/go-metrics/internal/config"
+	"github.com/hikjik/go-metrics/internal/metrics"
+	"github.com/hikjik/go-metrics/internal/prometheus"
+	"github.com/hikjik/go-metrics/internal/scheduler"
+	"github.com/hikjik/go-metrics/internal/service"
+)
+
+// TargetLabel метка, содержащая адрес цели, с которой получена метрика
+const TargetLabel = "target"
+
+// exportedPrefix добавляется к меткам цели, совпадающим с метками, которые добавляет Scraper
+const exportedPrefix = "exported_"
+
+type Scraper struct {
+	Service  *service.Service
+	Client   *http.Client
+	trackers map[string]*metrics.DeltaTracker
+	Targets  []string
+	Interval time.Duration
+}
+
+// NewScraper создает Scraper, сохраняющий метрики целей cfg.ScrapeTargets
+// с помощью общего для всех транспортов сервиса svc
+func NewScraper(cfg config.ServerConfig, svc *service.Service) *Scraper {
+	trackers := make(map[string]*metrics.DeltaTracker, len(cfg.ScrapeTargets))
+	for _, target := range cfg.ScrapeTargets {
+		trackers[target] = metrics.NewCumulativeTracker()
+	}
+	return &Scraper{
+		Service:  svc,
+		Client:   &http.Client{},
+		trackers: trackers,
+		Targets:  cfg.ScrapeTargets,
+		Interval: cfg.ScrapeInterval,
+	}
+}
+
+// Run опрашивает цели с периодом Interval до завершения контекста ctx
+func (s *Scraper) Run(ctx context.Context) {
+	tasks := scheduler.New()
+	for _, target := range s.Targets {
+		target := target
+		tasks.Add(ctx, func() {
+			if err := s.scrape(ctx, target); err != nil {
+				log.Warn().Err(err).Msgf("Failed to scrape target %s", target)
+			}
+		}, s.Interval)
+	}
+
+	<-ctx.Done()
+	tasks.Stop()
+}
+
+// scrape получает метрики цели target и сохраняет их с меткой target.
+// Накопленные значения счетчиков сохраняются в виде приращений с предыдущего успешного опроса,
+// первое значение счетчика после запуска сервера только запоминается.
+func (s *Scraper) scrape(ctx context.Context, target string) error {
+	ctx, cancel := context.WithTimeout(ctx, s.Interval)
+	defer cancel()
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, targetURL(target), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	request.Header.Set("Accept", prometheus.ContentType)
+
+	response, err := s.Client.Do(request)
+	if err != nil {
+		return fmt.Errorf("failed to get metrics: %w", err)
+	}
+	defer func() {
+		if err = response.Body.Close(); err != nil {
+			log.Warn().Err(err).Msg("Failed to close response body")
+		}
+	}()
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected response status: %s", response.Status)
+	}
+
+	collection, err := prometheus.Parse(response.Body)
+	if err != nil {
+		return err
+	}
+	for _, m := range collection {
+		m.Labels = withTarget(m.Labels, target)
+	}
+
+	return s.trackers[target].Apply(collection, func(collection []*metrics.Metric) error {
+		errs, putErr := s.Service.PutBatchUnsigned(ctx, collection)
+		if putErr != nil {
+			return putErr
+		}
+		for i, m := range collection {
+			if errs[i] != nil {
+				log.Info().Msgf("Rejected metric %s of type %s: %v", m.ID, m.MType, errs[i])
+			}
+		}
+		return nil
+	})
+}
+
+// targetURL возвращает адрес страницы метрик цели.
+// Для целей вида host:port используется путь /metrics.
+func targetURL(target string) string {
+	if strings.Contains(target, "://") {
+		return target
+	}
+	return "http://" + target + "/metrics"
+}
+
+// withTarget добавляет к меткам метрики метку target.
+// Собственная метка target цели сохраняется под именем exported_target.
+func withTarget(labels map[string]string, target string) map[string]string {
+	if labels == nil {
+		labels = make(map[string]string, 1)
+	}
+	if value, ok := labels[TargetLabel]; ok {
+		labels[exportedPrefix+TargetLabel] = value
+	}
+	labels[TargetLabel] = target
+	return labels
+}
@@ -0,0 +1,74 @@
+package scrape
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hikjik/go-metrics/internal/config"
+	"github.com/hikjik/go-metrics/internal/metrics"
+	"github.com/hikjik/go-metrics/internal/service"
+)
+
+func TestScrape(t *testing.T) {
+	requests := 0
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/metrics" {
+			http.NotFound(w, r)
+			return
+		}
+		requests++
+		_, err := w.Write([]byte(
+			"# TYPE requests_total counter\n" +
+				`requests_total{target="db"} ` + map[int]string{1: "10", 2: "25"}[requests] + "\n" +
+				"# TYPE temperature gauge\n" +
+				"temperature 36.6\n"))
+		require.NoError(t, err)
+	}))
+	defer target.Close()
+	address := target.Listener.Addr().String()
+
+	cfg := config.ServerConfig{
+		ScrapeTargets:  []string{address},
+		ScrapeInterval: time.Second,
+		StorageConfig: config.StorageConfig{
+			StoreInterval: time.Second * 300,
+		},
+	}
+	svc, err := service.New(context.Background(), cfg)
+	require.NoError(t, err)
+	scraper := NewScraper(cfg, svc)
+
+	ctx := context.Background()
+	counter := &metrics.Metric{
+		ID:     "requests",
+		MType:  metrics.CounterType,
+		Labels: map[string]string{TargetLabel: address, "exported_target": "db"},
+	}
+	require.NoError(t, scraper.scrape(ctx, address))
+	assert.Error(t, svc.Storage.Get(ctx, counter))
+
+	require.NoError(t, scraper.scrape(ctx, address))
+	require.NoError(t, svc.Storage.Get(ctx, counter))
+	assert.Equal(t, int64(15), *counter.Delta)
+
+	gauge := &metrics.Metric{
+		ID:     "temperature",
+		MType:  metrics.GaugeType,
+		Labels: map[string]string{TargetLabel: address},
+	}
+	require.NoError(t, svc.Storage.Get(ctx, gauge))
+	assert.Equal(t, 36.6, *gauge.Value)
+
+	assert.Error(t, scraper.scrape(ctx, "http://"+address+"/missing"))
+}
+
+func TestTargetURL(t *testing.T) {
+	assert.Equal(t, "http://localhost:9100/metrics", targetURL("localhost:9100"))
+	assert.Equal(t, "https://example.com/stats", targetURL("https://example.com/stats"))
+}
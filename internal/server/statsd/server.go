@@ -0,0 +1,201 @@
+// Package statsd содержит реализацию сервера, принимающего метрики
+// в формате StatsD по протоколам UDP и TCP.
+package statsd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/hikjik/go-metrics/internal/config"
+	"github.com/hikjik/go-metrics/internal/metrics"
+	"github.com/hikjik/go-metrics/internal/service"
+	"github.com/hikjik/go-metrics/internal/statsd"
+	"github.com/hikjik/go-metrics/internal/storage"
+)
+
+// maxPacketSize максимальный размер UDP-пакета
+const maxPacketSize = 65535
+
+type Server struct {
+	Service *service.Service
+	Address string
+	// mu упорядочивает обновления, зависящие от сохраненного значения метрики
+	mu sync.Mutex
+}
+
+// NewServer создает StatsD-сервер с адресом cfg.StatsDAddress
+func NewServer(cfg config.ServerConfig, svc *service.Service) *Server {
+	return &Server{
+		Service: svc,
+		Address: cfg.StatsDAddress,
+	}
+}
+
+// Run принимает метрики по протоколам UDP и TCP до завершения контекста ctx
+func (s *Server) Run(ctx context.Context) {
+	packetConn, err := net.ListenPacket("udp", s.Address)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to start statsd udp listener")
+	}
+	listener, err := net.Listen("tcp", s.Address)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to start statsd tcp listener")
+	}
+
+	go func() {
+		<-ctx.Done()
+		if err := packetConn.Close(); err != nil {
+			log.Error().Err(err).Msg("Failed to close statsd udp listener")
+		}
+		if err := listener.Close(); err != nil {
+			log.Error().Err(err).Msg("Failed to close statsd tcp listener")
+		}
+	}()
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		s.serveUDP(ctx, packetConn)
+	}()
+	go func() {
+		defer wg.Done()
+		s.serveTCP(ctx, listener)
+	}()
+	wg.Wait()
+}
+
+func (s *Server) serveUDP(ctx context.Context, conn net.PacketConn) {
+	buf := make([]byte, maxPacketSize)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if errors.Is(err, net.ErrClosed) {
+			return
+		}
+		if err != nil {
+			log.Warn().Err(err).Msg("Failed to read statsd packet")
+			continue
+		}
+		if !s.allowed(addr) {
+			continue
+		}
+
+		for _, line := range bytes.Split(buf[:n], []byte("\n")) {
+			s.handleLine(ctx, string(bytes.TrimSpace(line)))
+		}
+	}
+}
+
+func (s *Server) serveTCP(ctx context.Context, listener net.Listener) {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for {
+		conn, err := listener.Accept()
+		if errors.Is(err, net.ErrClosed) {
+			return
+		}
+		if err != nil {
+			log.Warn().Err(err).Msg("Failed to accept statsd connection")
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.serveConn(ctx, conn)
+		}()
+	}
+}
+
+func (s *Server) serveConn(ctx context.Context, conn net.Conn) {
+	defer func() {
+		if err := conn.Close(); err != nil {
+			log.Warn().Err(err).Msg("Failed to close statsd connection")
+		}
+	}()
+
+	if !s.allowed(conn.RemoteAddr()) {
+		return
+	}
+
+	go func() {
+		// прерываем чтение при завершении работы сервера
+		<-ctx.Done()
+		_ = conn.Close()
+	}()
+
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		s.handleLine(ctx, string(bytes.TrimSpace(scanner.Bytes())))
+	}
+	if err := scanner.Err(); err != nil && !errors.Is(err, net.ErrClosed) {
+		log.Warn().Err(err).Msg("Failed to read statsd connection")
+	}
+}
+
+func (s *Server) allowed(addr net.Addr) bool {
+	access := s.Service.ACL
+	return access.Allowed(access.ClientIP(addr.String(), nil, nil))
+}
+
+// handleLine сохраняет значение метрики из строки в формате StatsD
+func (s *Server) handleLine(ctx context.Context, line string) {
+	if line == "" {
+		return
+	}
+
+	sample, err := statsd.Parse(line)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to parse statsd line")
+		return
+	}
+
+	if err = s.put(ctx, sample); err != nil {
+		log.Warn().Err(err).Msgf("Failed to put statsd metric %s", sample.Name)
+	}
+}
+
+func (s *Server) put(ctx context.Context, sample statsd.Sample) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var m *metrics.Metric
+	switch sample.Type {
+	case statsd.CounterType:
+		m = metrics.NewCounter(sample.Name, sample.Delta())
+	case statsd.GaugeType:
+		m = metrics.NewGauge(sample.Name, sample.Value)
+		if sample.Relative {
+			current := &metrics.Metric{ID: sample.Name, MType: metrics.GaugeType, Labels: sample.Labels}
+			err := s.Service.GetUnsigned(ctx, current)
+			if err != nil && !errors.Is(err, storage.ErrNotFound) {
+				return err
+			}
+			if err == nil {
+				*m.Value += *current.Value
+			}
+		}
+	default:
+		value := sample.Value
+		if sample.Type == statsd.TimerType {
+			// таймеры StatsD передаются в миллисекундах, гистограммы хранятся в секундах
+			value /= 1000
+		}
+		bounds, err := s.Service.HistogramBounds(ctx, sample.Name, sample.Labels)
+		if err != nil {
+			return err
+		}
+		m = metrics.NewHistogram(sample.Name, bounds)
+		m.Histogram.ObserveN(value, sample.Count())
+	}
+	m.Labels = sample.Labels
+
+	return s.Service.Put(ctx, m)
+}
@@ -0,0 +1,105 @@
+package statsd
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hikjik/go-metrics/internal/config"
+	"github.com/hikjik/go-metrics/internal/metrics"
+	"github.com/hikjik/go-metrics/internal/service"
+	"github.com/hikjik/go-metrics/internal/statsd"
+)
+
+func newTestServer(t *testing.T, address string) *Server {
+	cfg := config.ServerConfig{
+		StatsDAddress: address,
+		StorageConfig: config.StorageConfig{
+			StoreInterval: time.Second * 300,
+		},
+	}
+	svc, err := service.New(context.Background(), cfg)
+	require.NoError(t, err)
+	return NewServer(cfg, svc)
+}
+
+func TestHandleLine(t *testing.T) {
+	s := newTestServer(t, "")
+	ctx := context.Background()
+
+	for _, line := range []string{
+		"requests:1|c",
+		"requests:2|c|@0.5",
+		"memory:10|g",
+		"memory:-3|g",
+		"latency:250|ms|@0.5|#env:prod",
+		"invalid line",
+		"memory:Inf|g",
+		"latency:NaN|ms|#env:prod",
+		"latency:1e308|ms|@0.0001|#env:prod",
+		"requests:1e300|c",
+		"level:1e308|g",
+		"level:+1e308|g",
+	} {
+		s.handleLine(ctx, line)
+	}
+
+	counter := &metrics.Metric{ID: "requests", MType: metrics.CounterType}
+	require.NoError(t, s.Service.Storage.Get(ctx, counter))
+	assert.Equal(t, int64(5), *counter.Delta)
+
+	gauge := &metrics.Metric{ID: "memory", MType: metrics.GaugeType}
+	require.NoError(t, s.Service.Storage.Get(ctx, gauge))
+	assert.Equal(t, 7.0, *gauge.Value)
+
+	level := &metrics.Metric{ID: "level", MType: metrics.GaugeType}
+	require.NoError(t, s.Service.Storage.Get(ctx, level))
+	assert.Equal(t, 1e308, *level.Value)
+
+	histogram := &metrics.Metric{
+		ID:     "latency",
+		MType:  metrics.HistogramType,
+		Labels: map[string]string{"env": "prod"},
+	}
+	require.NoError(t, s.Service.Storage.Get(ctx, histogram))
+	assert.Equal(t, uint64(2), histogram.Histogram.Count)
+	assert.InDelta(t, 0.5, histogram.Histogram.Sum, 1e-9)
+
+	// малая частота выборки не приводит к миллиардам наблюдений
+	s.handleLine(ctx, "sparse:1|ms|@0.000000001")
+	sparse := &metrics.Metric{ID: "sparse", MType: metrics.HistogramType}
+	require.NoError(t, s.Service.Storage.Get(ctx, sparse))
+	assert.Equal(t, uint64(statsd.MaxCount), sparse.Histogram.Count)
+}
+
+func TestRunUDP(t *testing.T) {
+	s := newTestServer(t, "127.0.0.1:0")
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s.Address = conn.LocalAddr().String()
+	require.NoError(t, conn.Close())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.Run(ctx)
+		close(done)
+	}()
+
+	client, err := net.Dial("udp", s.Address)
+	require.NoError(t, err)
+	defer client.Close()
+
+	counter := &metrics.Metric{ID: "packets", MType: metrics.CounterType}
+	require.Eventually(t, func() bool {
+		_, _ = client.Write([]byte("packets:1|c\nignored\n"))
+		return s.Service.Storage.Get(context.Background(), counter) == nil
+	}, time.Second*5, time.Millisecond*50)
+
+	cancel()
+	<-done
+}
@@ -0,0 +1,228 @@
+// Package service содержит не зависящую от транспорта логику сервера по сбору метрик.
+// Серверы HTTP и gRPC используют общий экземпляр Service, поэтому метрики,
+// принятые по одному протоколу, доступны по другому.
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/hikjik/go-metrics/internal/acl"
+	"github.com/hikjik/go-metrics/internal/config"
+	"github.com/hikjik/go-metrics/internal/encryption"
+	"github.com/hikjik/go-metrics/internal/encryption/rsa"
+	"github.com/hikjik/go-metrics/internal/metrics"
+	"github.com/hikjik/go-metrics/internal/query"
+	"github.com/hikjik/go-metrics/internal/storage"
+)
+
+var (
+	// ErrInvalidHash ошибка проверки подписи метрики
+	ErrInvalidHash = errors.New("invalid hash")
+	// ErrPingNotSupported ошибка проверки доступности хранилища, не являющегося базой данных
+	ErrPingNotSupported = errors.New("storage does not support ping")
+)
+
+// Service предоставляет доступ к хранилищу метрик с проверкой подписи
+// и расшифровкой данных, а также общие для всех транспортов правила доступа ACL
+type Service struct {
+	Storage   storage.Storage
+	Signer    metrics.Signer
+	Decrypter encryption.Decrypter
+	ACL       *acl.ACL
+	key       []byte
+}
+
+// New создает экземпляр Service с хранилищем и ключами, заданными в настройках сервера
+func New(ctx context.Context, cfg config.ServerConfig) (*Service, error) {
+	store, err := storage.New(ctx, cfg.StorageConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create storage: %w", err)
+	}
+
+	decrypter, err := rsa.NewDecrypter(cfg.EncryptionKeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to setup rsa decryption: %w", err)
+	}
+
+	allow := cfg.AllowedSubnets
+	if cfg.TrustedSubnet != "" {
+		allow = append([]string{cfg.TrustedSubnet}, allow...)
+	}
+	access, err := acl.New(allow, cfg.DeniedSubnets, cfg.TrustedProxies)
+	if err != nil {
+		return nil, fmt.Errorf("failed to setup acl: %w", err)
+	}
+
+	return &Service{
+		Storage:   store,
+		Signer:    metrics.NewHMACSigner(cfg.SignatureKey),
+		Decrypter: decrypter,
+		ACL:       access,
+		key:       []byte(cfg.SignatureKey),
+	}, nil
+}
+
+// Get получает из хранилища значение метрики и подписывает его
+func (s *Service) Get(ctx context.Context, m *metrics.Metric) error {
+	if err := s.Storage.Get(ctx, m); err != nil {
+		return err
+	}
+	if s.Signer != nil {
+		if err := s.Signer.Sign(m); err != nil {
+			return fmt.Errorf("failed to set hash: %w", err)
+		}
+	}
+	return nil
+}
+
+// GetUnsigned получает из хранилища значение метрики без подписи
+func (s *Service) GetUnsigned(ctx context.Context, m *metrics.Metric) error {
+	return s.Storage.Get(ctx, m)
+}
+
+// List возвращает значения всех сохраненных метрик
+func (s *Service) List(ctx context.Context) ([]*metrics.Metric, error) {
+	return s.Storage.List(ctx)
+}
+
+// Put сохраняет значение метрики без проверки подписи
+func (s *Service) Put(ctx context.Context, m *metrics.Metric) error {
+	return s.Storage.Put(ctx, m)
+}
+
+// PutBatchUnsigned сохраняет значения набора метрик без проверки подписи.
+// Для каждой метрики возвращается ошибка сохранения или nil. Ошибка сбоя означает,
+// что не сохранена ни одна метрика набора.
+func (s *Service) PutBatchUnsigned(ctx context.Context, collection []*metrics.Metric) ([]error, error) {
+	return s.Storage.PutBatch(ctx, collection)
+}
+
+// PutSigned проверяет подпись метрики и сохраняет ее значение
+func (s *Service) PutSigned(ctx context.Context, m *metrics.Metric) error {
+	if err := s.validateHash(m); err != nil {
+		return err
+	}
+	return s.Storage.Put(ctx, m)
+}
+
+// PutBatch проверяет подписи и сохраняет значения набора метрик.
+// Некорректные метрики не прерывают сохранение набора: для каждой метрики
+// возвращается статус с причиной отказа. Ошибка возвращается только при сбое,
+// после устранения которого набор можно отправить повторно: в этом случае
+// не сохраняется ни одна метрика набора, и приращения счетчиков не учитываются дважды.
+func (s *Service) PutBatch(ctx context.Context, collection []*metrics.Metric) ([]metrics.Status, error) {
+	errs := make([]error, len(collection))
+	valid := make([]*metrics.Metric, 0, len(collection))
+	for i, m := range collection {
+		if errs[i] = s.validateHash(m); errs[i] == nil {
+			valid = append(valid, m)
+		}
+	}
+
+	stored, err := s.Storage.PutBatch(ctx, valid)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]metrics.Status, 0, len(collection))
+	for i, m := range collection {
+		if errs[i] == nil {
+			errs[i], stored = stored[0], stored[1:]
+		}
+		statuses = append(statuses, metrics.NewStatus(m, errs[i]))
+	}
+	return statuses, nil
+}
+
+// validateHash проверяет подпись метрики, если задан ключ подписи.
+// Если подпись не удалось вычислить из-за некорректного значения метрики,
+// возвращается та же ошибка, что и при сохранении такой метрики в хранилище.
+func (s *Service) validateHash(m *metrics.Metric) error {
+	if s.Signer == nil {
+		return nil
+	}
+	ok, err := s.Signer.Validate(m)
+	if err != nil {
+		var byteErr hex.InvalidByteError
+		switch {
+		case errors.As(err, &byteErr) || errors.Is(err, hex.ErrLength):
+			return fmt.Errorf("%w: %v", ErrInvalidHash, err)
+		case m.MType != metrics.CounterType && m.MType != metrics.GaugeType && m.MType != metrics.HistogramType:
+			return storage.ErrUnknownMetricType
+		default:
+			return fmt.Errorf("%w: %v", storage.ErrBadArgument, err)
+		}
+	}
+	if !ok {
+		return ErrInvalidHash
+	}
+	return nil
+}
+
+// Range возвращает историю значений метрики, агрегированную по интервалам
+func (s *Service) Range(ctx context.Context, r query.Request) (*query.Result, error) {
+	return query.Range(ctx, s.Storage, r)
+}
+
+// HistogramBounds возвращает границы интервалов сохраненной гистограммы с именем id
+// и метками labels или metrics.DefaultBuckets, если гистограмма еще не сохранялась
+func (s *Service) HistogramBounds(ctx context.Context, id string, labels map[string]string) ([]float64, error) {
+	m := &metrics.Metric{ID: id, MType: metrics.HistogramType, Labels: labels}
+	switch err := s.Storage.Get(ctx, m); {
+	case err == nil:
+		return m.Histogram.Bounds, nil
+	case errors.Is(err, storage.ErrNotFound):
+		return metrics.DefaultBuckets, nil
+	default:
+		return nil, err
+	}
+}
+
+// Decrypt расшифровывает данные, полученные от агента.
+// Если ключ шифрования не задан, данные возвращаются без изменений.
+func (s *Service) Decrypt(data []byte) ([]byte, error) {
+	if s.Decrypter == nil {
+		return data, nil
+	}
+	return s.Decrypter.Decrypt(data)
+}
+
+// ValidateBody проверяет подпись hash тела запроса data, вычисленную
+// по алгоритму HMAC-SHA256 и записанную в шестнадцатеричном виде.
+// Если ключ подписи не задан, проверка не выполняется.
+func (s *Service) ValidateBody(data []byte, hash string) error {
+	if len(s.key) == 0 {
+		return nil
+	}
+
+	decoded, err := hex.DecodeString(hash)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidHash, err)
+	}
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write(data)
+	if !hmac.Equal(mac.Sum(nil), decoded) {
+		return ErrInvalidHash
+	}
+	return nil
+}
+
+// Ping проверяет доступность базы данных
+func (s *Service) Ping(ctx context.Context) error {
+	db, ok := s.Storage.(*storage.DBStorage)
+	if !ok {
+		return ErrPingNotSupported
+	}
+	return db.Ping(ctx)
+}
+
+// IsRejected возвращает true, если ошибка вызвана некорректной метрикой,
+// и повторная отправка метрики не имеет смысла
+func IsRejected(err error) bool {
+	return errors.Is(err, ErrInvalidHash) || storage.IsRejected(err)
+}
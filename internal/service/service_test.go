@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hikjik/go-metrics/internal/config"
+	"github.com/hikjik/go-metrics/internal/metrics"
+)
+
+func newTestService(t *testing.T, key string, subnet string) *Service {
+	svc, err := New(context.Background(), config.ServerConfig{
+		SignatureKey:  key,
+		TrustedSubnet: subnet,
+		StorageConfig: config.StorageConfig{
+			StoreInterval: time.Second * 300,
+		},
+	})
+	require.NoError(t, err)
+	return svc
+}
+
+func TestPutBatch(t *testing.T) {
+	svc := newTestService(t, "secret", "")
+	signer := metrics.NewHMACSigner("secret")
+
+	signed := metrics.NewCounter("PollCount", 5)
+	require.NoError(t, signer.Sign(signed))
+	unsigned := metrics.NewGauge("Alloc", 1.5)
+	unknown := &metrics.Metric{ID: "Unknown", MType: "unknown"}
+	empty := &metrics.Metric{ID: "Empty", MType: metrics.CounterType}
+	malformed := metrics.NewGauge("Malformed", 1)
+	malformed.Hash = "hash"
+
+	statuses, err := svc.PutBatch(context.Background(), []*metrics.Metric{signed, unsigned, unknown, empty, malformed})
+	require.NoError(t, err)
+	require.Len(t, statuses, 5)
+	assert.True(t, statuses[0].Accepted())
+	assert.Equal(t, "invalid hash", statuses[1].Error)
+	assert.Equal(t, "unknown metric type", statuses[2].Error)
+	assert.Equal(t, "bad argument: counter has no value", statuses[3].Error)
+	assert.Contains(t, statuses[4].Error, "invalid hash: encoding/hex")
+
+	m := &metrics.Metric{ID: "PollCount", MType: metrics.CounterType}
+	require.NoError(t, svc.Get(context.Background(), m))
+	assert.Equal(t, int64(5), *m.Delta)
+	ok, err := signer.Validate(m)
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestTrustedSubnet(t *testing.T) {
+	tests := []struct {
+		name   string
+		subnet string
+		ip     string
+		want   bool
+	}{
+		{name: "No subnet", ip: "10.0.0.1", want: true},
+		{name: "Trusted address", subnet: "192.168.0.0/24", ip: "192.168.0.10", want: true},
+		{name: "Untrusted address", subnet: "192.168.0.0/24", ip: "10.0.0.1", want: false},
+		{name: "Invalid address", subnet: "192.168.0.0/24", ip: "", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newTestService(t, "", tt.subnet)
+			assert.Equal(t, tt.want, svc.ACL.Allowed(net.ParseIP(tt.ip)))
+		})
+	}
+}
+
+func TestNewInvalidSubnet(t *testing.T) {
+	_, err := New(context.Background(), config.ServerConfig{
+		TrustedSubnet: "invalid",
+		StorageConfig: config.StorageConfig{StoreInterval: time.Second},
+	})
+	assert.Error(t, err)
+}
+
+func TestValidateBody(t *testing.T) {
+	data := []byte("cpu usage=1.5")
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write(data)
+	hash := hex.EncodeToString(mac.Sum(nil))
+
+	svc := newTestService(t, "secret", "")
+	assert.NoError(t, svc.ValidateBody(data, hash))
+	assert.ErrorIs(t, svc.ValidateBody([]byte("cpu usage=2"), hash), ErrInvalidHash)
+	assert.ErrorIs(t, svc.ValidateBody(data, "invalid"), ErrInvalidHash)
+
+	assert.NoError(t, newTestService(t, "", "").ValidateBody(data, ""))
+}
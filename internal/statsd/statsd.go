@@ -0,0 +1,128 @@
+// Package statsd предназначен для разбора метрик в формате StatsD.
+//
+// Поддерживаются строки вида name:value|type[|@rate][|#tag1:value1,tag2:value2],
+// где type - c (счетчик), g (gauge), ms (таймер), h или d (гистограмма).
+// Теги в формате DogStatsD преобразуются в метки метрики.
+package statsd
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Типы метрик StatsD
+const (
+	CounterType      = "c"
+	GaugeType        = "g"
+	TimerType        = "ms"
+	HistogramType    = "h"
+	DistributionType = "d"
+)
+
+// MaxCount максимальное количество значений, которое может представлять один сэмпл
+// с малой частотой выборки
+const MaxCount = 1000000
+
+// ErrInvalidLine ошибка разбора строки в формате StatsD
+var ErrInvalidLine = errors.New("invalid statsd line")
+
+// Sample содержит значение метрики, полученное из строки в формате StatsD
+type Sample struct {
+	Labels map[string]string
+	Name   string
+	Type   string
+	Value  float64
+	// Rate частота выборки значений метрики из интервала (0, 1]
+	Rate float64
+	// Relative означает, что значение метрики типа GaugeType задано
+	// как изменение текущего значения, например, gauge:+5|g
+	Relative bool
+}
+
+// Parse разбирает строку в формате StatsD. Значения NaN и ±Inf, а также значения счетчиков,
+// приращение которых с учетом частоты выборки не помещается в int64, не принимаются.
+func Parse(line string) (Sample, error) {
+	sample := Sample{Rate: 1}
+
+	parts := strings.Split(line, "|")
+	i := strings.LastIndex(parts[0], ":")
+	if i <= 0 || len(parts) < 2 {
+		return sample, fmt.Errorf("%w: %s", ErrInvalidLine, line)
+	}
+	sample.Name = parts[0][:i]
+	value := parts[0][i+1:]
+	sample.Type = parts[1]
+
+	switch sample.Type {
+	case CounterType, GaugeType, TimerType, HistogramType, DistributionType:
+	default:
+		return sample, fmt.Errorf("%w: unsupported metric type %s", ErrInvalidLine, sample.Type)
+	}
+
+	if sample.Type == GaugeType && (strings.HasPrefix(value, "+") || strings.HasPrefix(value, "-")) {
+		sample.Relative = true
+	}
+	var err error
+	sample.Value, err = strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(sample.Value) || math.IsInf(sample.Value, 0) {
+		return sample, fmt.Errorf("%w: invalid value %s", ErrInvalidLine, value)
+	}
+
+	for _, part := range parts[2:] {
+		switch {
+		case strings.HasPrefix(part, "@"):
+			sample.Rate, err = strconv.ParseFloat(part[1:], 64)
+			if err != nil || sample.Rate <= 0 || sample.Rate > 1 {
+				return sample, fmt.Errorf("%w: invalid sample rate %s", ErrInvalidLine, part)
+			}
+		case strings.HasPrefix(part, "#"):
+			sample.Labels = parseTags(part[1:])
+		default:
+			return sample, fmt.Errorf("%w: unknown field %s", ErrInvalidLine, part)
+		}
+	}
+
+	if sample.Type == CounterType {
+		delta := math.Round(sample.Value / sample.Rate)
+		// float64(math.MaxInt64) равно 2^63 и уже не помещается в int64
+		if delta < math.MinInt64 || delta >= math.MaxInt64 {
+			return sample, fmt.Errorf("%w: counter value %s is out of range", ErrInvalidLine, value)
+		}
+	}
+	return sample, nil
+}
+
+// Delta возвращает приращение счетчика с учетом частоты выборки Rate
+func (s Sample) Delta() int64 {
+	return int64(math.Round(s.Value / s.Rate))
+}
+
+// Count возвращает количество значений, которое представляет сэмпл с учетом
+// частоты выборки Rate. Результат ограничен значением MaxCount.
+func (s Sample) Count() uint64 {
+	count := math.Round(1 / s.Rate)
+	if count > MaxCount {
+		return MaxCount
+	}
+	return uint64(count)
+}
+
+// parseTags разбирает теги DogStatsD вида tag1:value1,tag2. Тег без значения
+// преобразуется в метку с пустым значением.
+func parseTags(value string) map[string]string {
+	labels := make(map[string]string)
+	for _, tag := range strings.Split(value, ",") {
+		if tag == "" {
+			continue
+		}
+		if i := strings.Index(tag, ":"); i >= 0 {
+			labels[tag[:i]] = tag[i+1:]
+		} else {
+			labels[tag] = ""
+		}
+	}
+	return labels
+}
@@ -0,0 +1,85 @@
+package statsd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    string
+		want    Sample
+		wantErr bool
+	}{
+		{
+			name: "Counter",
+			line: "requests:1|c",
+			want: Sample{Name: "requests", Type: CounterType, Value: 1, Rate: 1},
+		},
+		{
+			name: "Counter with sample rate",
+			line: "requests:2|c|@0.1",
+			want: Sample{Name: "requests", Type: CounterType, Value: 2, Rate: 0.1},
+		},
+		{
+			name: "Gauge",
+			line: "api.memory:3.2|g",
+			want: Sample{Name: "api.memory", Type: GaugeType, Value: 3.2, Rate: 1},
+		},
+		{
+			name: "Relative gauge",
+			line: "connections:-4|g",
+			want: Sample{Name: "connections", Type: GaugeType, Value: -4, Rate: 1, Relative: true},
+		},
+		{
+			name: "Timer with tags",
+			line: "latency:320|ms|@0.5|#env:prod,canary",
+			want: Sample{
+				Name:   "latency",
+				Type:   TimerType,
+				Value:  320,
+				Rate:   0.5,
+				Labels: map[string]string{"env": "prod", "canary": ""},
+			},
+		},
+		{name: "Missing type", line: "requests:1", wantErr: true},
+		{name: "Missing name", line: ":1|c", wantErr: true},
+		{name: "Invalid value", line: "requests:one|c", wantErr: true},
+		{name: "Unsupported type", line: "users:42|s", wantErr: true},
+		{name: "Invalid sample rate", line: "requests:1|c|@2", wantErr: true},
+		{name: "Unknown field", line: "requests:1|c|x", wantErr: true},
+		{name: "NaN gauge", line: "queue:NaN|g", wantErr: true},
+		{name: "Infinite gauge", line: "queue:+Inf|g", wantErr: true},
+		{name: "NaN timer", line: "latency:NaN|ms", wantErr: true},
+		{name: "Counter out of range", line: "requests:1e300|c", wantErr: true},
+		{name: "Counter out of range with sample rate", line: "requests:1e17|c|@0.01", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sample, err := Parse(tt.line)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidLine)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, sample)
+		})
+	}
+}
+
+func TestSampleCount(t *testing.T) {
+	for _, tt := range []struct {
+		rate float64
+		want uint64
+	}{
+		{rate: 1, want: 1},
+		{rate: 0.5, want: 2},
+		{rate: 0.3, want: 3},
+		{rate: 0.000000001, want: MaxCount},
+	} {
+		assert.Equal(t, tt.want, Sample{Rate: tt.rate}.Count(), tt.rate)
+	}
+}
@@ -3,15 +3,93 @@ package storage
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
+	"time"
 
 	_ "github.com/jackc/pgx/v4/stdlib"
 	"github.com/openlyinc/pointy"
+	"github.com/rs/zerolog/log"
 
 	"github.com/hikjik/go-metrics/internal/config"
 	"github.com/hikjik/go-metrics/internal/metrics"
 )
 
+// schema создает таблицы хранилища. Метки метрики хранятся в столбце labels
+// в каноническом виде metrics.EncodeLabels, временной ряд определяется парой (name, labels).
+// Гистограммы хранятся в формате JSON. Первичные ключи таблиц, созданных предыдущими
+// версиями сервера, перестраиваются один раз, если они еще не включают столбец labels.
+const schema = `CREATE TABLE IF NOT EXISTS counter(
+		name VARCHAR(128) NOT NULL,
+		labels TEXT NOT NULL DEFAULT '',
+		value BIGINT NOT NULL,
+		PRIMARY KEY (name, labels)
+	);
+	CREATE TABLE IF NOT EXISTS gauge(
+		name VARCHAR(128) NOT NULL,
+		labels TEXT NOT NULL DEFAULT '',
+		value DOUBLE PRECISION NOT NULL,
+		PRIMARY KEY (name, labels)
+	);
+	CREATE TABLE IF NOT EXISTS histogram(
+		name VARCHAR(128) NOT NULL,
+		labels TEXT NOT NULL DEFAULT '',
+		value TEXT NOT NULL,
+		PRIMARY KEY (name, labels)
+	);
+	CREATE TABLE IF NOT EXISTS counter_history(
+		name VARCHAR(128) NOT NULL,
+		labels TEXT NOT NULL DEFAULT '',
+		delta BIGINT NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	);
+	CREATE TABLE IF NOT EXISTS gauge_history(
+		name VARCHAR(128) NOT NULL,
+		labels TEXT NOT NULL DEFAULT '',
+		value DOUBLE PRECISION NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	);
+	CREATE TABLE IF NOT EXISTS histogram_history(
+		name VARCHAR(128) NOT NULL,
+		labels TEXT NOT NULL DEFAULT '',
+		value TEXT NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	);
+	ALTER TABLE counter ADD COLUMN IF NOT EXISTS labels TEXT NOT NULL DEFAULT '';
+	ALTER TABLE gauge ADD COLUMN IF NOT EXISTS labels TEXT NOT NULL DEFAULT '';
+	DO $$
+	BEGIN
+		-- таблицы, созданные до появления меток, имеют первичный ключ только по имени
+		IF NOT EXISTS (
+			SELECT 1 FROM pg_index i
+			JOIN pg_attribute a ON a.attrelid = i.indrelid AND a.attnum = ANY(i.indkey)
+			WHERE i.indrelid = 'counter'::regclass AND i.indisprimary AND a.attname = 'labels'
+		) THEN
+			ALTER TABLE counter DROP CONSTRAINT IF EXISTS counter_name_key;
+			ALTER TABLE counter DROP CONSTRAINT IF EXISTS counter_pkey;
+			ALTER TABLE counter ADD CONSTRAINT counter_pkey PRIMARY KEY (name, labels);
+		END IF;
+		IF NOT EXISTS (
+			SELECT 1 FROM pg_index i
+			JOIN pg_attribute a ON a.attrelid = i.indrelid AND a.attnum = ANY(i.indkey)
+			WHERE i.indrelid = 'gauge'::regclass AND i.indisprimary AND a.attname = 'labels'
+		) THEN
+			ALTER TABLE gauge DROP CONSTRAINT IF EXISTS gauge_name_key;
+			ALTER TABLE gauge DROP CONSTRAINT IF EXISTS gauge_pkey;
+			ALTER TABLE gauge ADD CONSTRAINT gauge_pkey PRIMARY KEY (name, labels);
+		END IF;
+	END $$;
+	ALTER TABLE counter_history ADD COLUMN IF NOT EXISTS labels TEXT NOT NULL DEFAULT '';
+	ALTER TABLE gauge_history ADD COLUMN IF NOT EXISTS labels TEXT NOT NULL DEFAULT '';
+	CREATE INDEX IF NOT EXISTS counter_history_series_created_at
+		ON counter_history (name, labels, created_at);
+	CREATE INDEX IF NOT EXISTS gauge_history_series_created_at
+		ON gauge_history (name, labels, created_at);
+	CREATE INDEX IF NOT EXISTS histogram_history_series_created_at
+		ON histogram_history (name, labels, created_at);`
+
 type DBStorage struct {
 	db *sql.DB
 }
@@ -22,16 +100,7 @@ func newDBStorage(ctx context.Context, cfg config.StorageConfig) (Storage, error
 		return nil, err
 	}
 
-	_, err = db.ExecContext(ctx,
-		`CREATE TABLE IF NOT EXISTS counter(
-		name VARCHAR(128) PRIMARY KEY UNIQUE NOT NULL,
-		value BIGINT NOT NULL
-	);
-	CREATE TABLE IF NOT EXISTS gauge(
-		name VARCHAR(128) PRIMARY KEY UNIQUE NOT NULL,
-		value DOUBLE PRECISION NOT NULL
-	);`)
-	if err != nil {
+	if _, err = db.ExecContext(ctx, schema); err != nil {
 		return nil, err
 	}
 
@@ -45,42 +114,173 @@ func (s *DBStorage) Ping(ctx context.Context) error {
 	return s.db.PingContext(ctx)
 }
 
+// querier выполняет запросы к базе данных непосредственно или в транзакции
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
 func (s *DBStorage) Put(ctx context.Context, metric *metrics.Metric) error {
+	if metric.MType != metrics.HistogramType {
+		return put(ctx, s.db, metric)
+	}
+	if !metrics.ValidateID(metric.ID) || !metrics.ValidateLabels(metric.Labels) || !metric.Histogram.Valid() {
+		return ErrBadArgument
+	}
+	// сложение гистограмм выполняется в транзакции с блокировкой строки временного ряда
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		return put(ctx, tx, metric)
+	})
+}
+
+// PutBatch сохраняет значения набора метрик в одной транзакции
+func (s *DBStorage) PutBatch(ctx context.Context, collection []*metrics.Metric) ([]error, error) {
+	errs := make([]error, len(collection))
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		for i, metric := range collection {
+			err := put(ctx, tx, metric)
+			if IsRejected(err) {
+				errs[i] = err
+				continue
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return errs, nil
+}
+
+// inTx выполняет функцию fn в транзакции, которая фиксируется, если fn не вернула ошибку
+func (s *DBStorage) inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err = tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			log.Warn().Err(err).Msg("Failed to rollback transaction")
+		}
+	}()
+
+	if err = fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// put сохраняет значение метрики с помощью q. Гистограммы должны сохраняться в транзакции.
+func put(ctx context.Context, q querier, metric *metrics.Metric) error {
+	if !metrics.ValidateID(metric.ID) || !metrics.ValidateLabels(metric.Labels) {
+		return ErrBadArgument
+	}
+
 	switch metric.MType {
 	case metrics.CounterType:
 		if metric.Delta == nil {
 			return ErrBadArgument
 		}
-		_, err := s.db.ExecContext(
+		_, err := q.ExecContext(
 			ctx,
-			"INSERT INTO counter (name, value) "+
-				"VALUES ($1, $2) "+
-				"ON CONFLICT(name) DO UPDATE SET value = counter.value + $2;",
-			metric.ID, *metric.Delta)
+			"WITH history AS (INSERT INTO counter_history (name, labels, delta) VALUES ($1, $3, $2)) "+
+				"INSERT INTO counter (name, labels, value) "+
+				"VALUES ($1, $3, $2) "+
+				"ON CONFLICT(name, labels) DO UPDATE SET value = counter.value + $2;",
+			metric.ID, *metric.Delta, metrics.EncodeLabels(metric.Labels))
 		return err
 	case metrics.GaugeType:
-		if metric.Value == nil {
+		if metric.Value == nil || math.IsNaN(*metric.Value) || math.IsInf(*metric.Value, 0) {
 			return ErrBadArgument
 		}
-		_, err := s.db.ExecContext(
+		_, err := q.ExecContext(
 			ctx,
-			"INSERT INTO gauge (name, value) "+
-				"VALUES ($1, $2) "+
-				"ON CONFLICT(name) DO UPDATE SET value = $2;",
-			metric.ID, *metric.Value)
+			"WITH history AS (INSERT INTO gauge_history (name, labels, value) VALUES ($1, $3, $2)) "+
+				"INSERT INTO gauge (name, labels, value) "+
+				"VALUES ($1, $3, $2) "+
+				"ON CONFLICT(name, labels) DO UPDATE SET value = $2;",
+			metric.ID, *metric.Value, metrics.EncodeLabels(metric.Labels))
 		return err
+	case metrics.HistogramType:
+		if !metric.Histogram.Valid() {
+			return ErrBadArgument
+		}
+		return putHistogram(ctx, q, metric)
 	default:
 		return ErrUnknownMetricType
 	}
 }
 
+// putHistogram добавляет значения гистограммы к сохраненной гистограмме временного ряда,
+// блокируя строку временного ряда до завершения транзакции
+func putHistogram(ctx context.Context, q querier, metric *metrics.Metric) error {
+	labels := metrics.EncodeLabels(metric.Labels)
+	delta, err := json.Marshal(metric.Histogram)
+	if err != nil {
+		return err
+	}
+
+	result, err := q.ExecContext(
+		ctx,
+		"INSERT INTO histogram (name, labels, value) "+
+			"VALUES ($1, $2, $3) "+
+			"ON CONFLICT(name, labels) DO NOTHING;",
+		metric.ID, labels, string(delta))
+	if err != nil {
+		return err
+	}
+	inserted, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if inserted == 0 {
+		row := q.QueryRowContext(
+			ctx,
+			"SELECT value FROM histogram WHERE name=$1 AND labels=$2 FOR UPDATE;",
+			metric.ID, labels)
+
+		var data string
+		if err = row.Scan(&data); err != nil {
+			return err
+		}
+		var histogram metrics.Histogram
+		if err = json.Unmarshal([]byte(data), &histogram); err != nil {
+			return err
+		}
+		if err = histogram.Add(metric.Histogram); err != nil {
+			return ErrBadArgument
+		}
+
+		var value []byte
+		if value, err = json.Marshal(histogram); err != nil {
+			return err
+		}
+		if _, err = q.ExecContext(
+			ctx,
+			"UPDATE histogram SET value = $3 WHERE name=$1 AND labels=$2;",
+			metric.ID, labels, string(value)); err != nil {
+			return err
+		}
+	}
+
+	_, err = q.ExecContext(
+		ctx,
+		"INSERT INTO histogram_history (name, labels, value) VALUES ($1, $2, $3);",
+		metric.ID, labels, string(delta))
+	return err
+}
+
 func (s *DBStorage) Get(ctx context.Context, metric *metrics.Metric) error {
 	switch metric.MType {
 	case metrics.CounterType:
 		row := s.db.QueryRowContext(
 			ctx,
-			"SELECT value FROM counter WHERE name=$1;",
-			metric.ID)
+			"SELECT value FROM counter WHERE name=$1 AND labels=$2;",
+			metric.ID, metrics.EncodeLabels(metric.Labels))
 
 		var delta int64
 		if err := row.Scan(&delta); err == nil {
@@ -92,8 +292,8 @@ func (s *DBStorage) Get(ctx context.Context, metric *metrics.Metric) error {
 	case metrics.GaugeType:
 		row := s.db.QueryRowContext(
 			ctx,
-			"SELECT value FROM gauge WHERE name=$1;",
-			metric.ID)
+			"SELECT value FROM gauge WHERE name=$1 AND labels=$2;",
+			metric.ID, metrics.EncodeLabels(metric.Labels))
 
 		var value float64
 		if err := row.Scan(&value); err == nil {
@@ -102,6 +302,22 @@ func (s *DBStorage) Get(ctx context.Context, metric *metrics.Metric) error {
 		} else {
 			return ErrNotFound
 		}
+	case metrics.HistogramType:
+		row := s.db.QueryRowContext(
+			ctx,
+			"SELECT value FROM histogram WHERE name=$1 AND labels=$2;",
+			metric.ID, metrics.EncodeLabels(metric.Labels))
+
+		var data string
+		if err := row.Scan(&data); err != nil {
+			return ErrNotFound
+		}
+		var histogram metrics.Histogram
+		if err := json.Unmarshal([]byte(data), &histogram); err != nil {
+			return fmt.Errorf("failed to decode histogram: %v", err)
+		}
+		metric.Histogram = &histogram
+		return nil
 	default:
 		return ErrUnknownMetricType
 	}
@@ -111,34 +327,117 @@ func (s *DBStorage) List(ctx context.Context) ([]*metrics.Metric, error) {
 	result := make([]*metrics.Metric, 0)
 
 	var (
-		id    string
-		value float64
-		delta int64
+		id     string
+		labels string
+		value  float64
+		delta  int64
 	)
 
-	rows, err := s.db.QueryContext(ctx, "SELECT name, value FROM gauge")
+	rows, err := s.db.QueryContext(ctx, "SELECT name, labels, value FROM gauge")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query db: %v", err)
+	}
+	for rows.Next() {
+		if err = rows.Scan(&id, &labels, &value); err != nil {
+			return nil, fmt.Errorf("failed to query db: %v", err)
+		}
+		metric := metrics.NewGauge(id, value)
+		if metric.Labels, err = metrics.DecodeLabels(labels); err != nil {
+			return nil, err
+		}
+		result = append(result, metric)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query db: %v", err)
+	}
+
+	rows, err = s.db.QueryContext(ctx, "SELECT name, labels, value FROM counter")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query db: %v", err)
+	}
+	for rows.Next() {
+		if err = rows.Scan(&id, &labels, &delta); err != nil {
+			return nil, fmt.Errorf("failed to query db: %v", err)
+		}
+		metric := metrics.NewCounter(id, delta)
+		if metric.Labels, err = metrics.DecodeLabels(labels); err != nil {
+			return nil, err
+		}
+		result = append(result, metric)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query db: %v", err)
+	}
+
+	var data string
+	rows, err = s.db.QueryContext(ctx, "SELECT name, labels, value FROM histogram")
 	if err != nil {
 		return nil, fmt.Errorf("failed to query db: %v", err)
 	}
 	for rows.Next() {
-		if err = rows.Scan(&id, &value); err != nil {
+		if err = rows.Scan(&id, &labels, &data); err != nil {
 			return nil, fmt.Errorf("failed to query db: %v", err)
 		}
-		result = append(result, metrics.NewGauge(id, value))
+		metric := &metrics.Metric{ID: id, MType: metrics.HistogramType}
+		if err = json.Unmarshal([]byte(data), &metric.Histogram); err != nil {
+			return nil, fmt.Errorf("failed to decode histogram: %v", err)
+		}
+		if metric.Labels, err = metrics.DecodeLabels(labels); err != nil {
+			return nil, err
+		}
+		result = append(result, metric)
 	}
 	if err = rows.Err(); err != nil {
 		return nil, fmt.Errorf("failed to query db: %v", err)
 	}
 
-	rows, err = s.db.QueryContext(ctx, "SELECT name, value FROM counter")
+	return result, nil
+}
+
+func (s *DBStorage) Range(ctx context.Context, metric *metrics.Metric, from, to time.Time) ([]Point, error) {
+	var query string
+	switch metric.MType {
+	case metrics.CounterType:
+		query = "SELECT created_at, delta FROM counter_history " +
+			"WHERE name=$1 AND labels=$2 AND created_at BETWEEN $3 AND $4 ORDER BY created_at;"
+	case metrics.GaugeType:
+		query = "SELECT created_at, value FROM gauge_history " +
+			"WHERE name=$1 AND labels=$2 AND created_at BETWEEN $3 AND $4 ORDER BY created_at;"
+	case metrics.HistogramType:
+		query = "SELECT created_at, value FROM histogram_history " +
+			"WHERE name=$1 AND labels=$2 AND created_at BETWEEN $3 AND $4 ORDER BY created_at;"
+	default:
+		return nil, ErrUnknownMetricType
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, metric.ID, metrics.EncodeLabels(metric.Labels), from, to)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query db: %v", err)
 	}
+	defer rows.Close()
+
+	result := make([]Point, 0)
 	for rows.Next() {
-		if err = rows.Scan(&id, &delta); err != nil {
+		var point Point
+		switch metric.MType {
+		case metrics.CounterType:
+			var delta int64
+			err = rows.Scan(&point.Timestamp, &delta)
+			point.Delta = pointy.Int64(delta)
+		case metrics.GaugeType:
+			var value float64
+			err = rows.Scan(&point.Timestamp, &value)
+			point.Value = pointy.Float64(value)
+		case metrics.HistogramType:
+			var data string
+			if err = rows.Scan(&point.Timestamp, &data); err == nil {
+				err = json.Unmarshal([]byte(data), &point.Histogram)
+			}
+		}
+		if err != nil {
 			return nil, fmt.Errorf("failed to query db: %v", err)
 		}
-		result = append(result, metrics.NewCounter(id, delta))
+		result = append(result, point)
 	}
 	if err = rows.Err(); err != nil {
 		return nil, fmt.Errorf("failed to query db: %v", err)
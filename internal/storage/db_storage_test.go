@@ -2,8 +2,12 @@ package storage
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"math"
 	"sort"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/openlyinc/pointy"
@@ -62,7 +66,7 @@ func TestGet(t *testing.T) {
 
 			if tt.err == nil {
 				mock.ExpectQuery(tt.sqlQuery).
-					WithArgs(tt.m.ID).
+					WithArgs(tt.m.ID, "").
 					WillReturnRows(mock.NewRows([]string{"value"}).AddRow(tt.target))
 			}
 			mock.ExpectClose()
@@ -98,31 +102,44 @@ func TestList(t *testing.T) {
 
 	gauges := []*metrics.Metric{
 		{ID: "RandomValue", MType: metrics.GaugeType, Value: pointy.Float64(1.0)},
-		{ID: "Alloc", MType: metrics.GaugeType, Value: pointy.Float64(2.0)},
+		{ID: "Alloc", MType: metrics.GaugeType, Value: pointy.Float64(2.0), Labels: map[string]string{"host": "a"}},
 	}
 
-	gRows := mock.NewRows([]string{"name", "value"})
+	gRows := mock.NewRows([]string{"name", "labels", "value"})
 	for _, g := range gauges {
-		gRows = gRows.AddRow(g.ID, *g.Value)
+		gRows = gRows.AddRow(g.ID, metrics.EncodeLabels(g.Labels), *g.Value)
 	}
-	mock.ExpectQuery("SELECT name, value FROM gauge").
+	mock.ExpectQuery("SELECT name, labels, value FROM gauge").
 		WillReturnRows(gRows)
 
 	counters := []*metrics.Metric{
-		{ID: "PollCount", MType: metrics.CounterType, Delta: pointy.Int64(1)},
+		{ID: "PollCount", MType: metrics.CounterType, Delta: pointy.Int64(1), Labels: map[string]string{"host": "b"}},
 		{ID: "Counter", MType: metrics.CounterType, Delta: pointy.Int64(2)},
 	}
-	cRows := mock.NewRows([]string{"name", "value"})
+	cRows := mock.NewRows([]string{"name", "labels", "value"})
 	for _, c := range counters {
-		cRows = cRows.AddRow(c.ID, *c.Delta)
+		cRows = cRows.AddRow(c.ID, metrics.EncodeLabels(c.Labels), *c.Delta)
 	}
-	mock.ExpectQuery("SELECT name, value FROM counter").
+	mock.ExpectQuery("SELECT name, labels, value FROM counter").
 		WillReturnRows(cRows)
+
+	histogram := metrics.NewHistogram("Latency", []float64{0.1, 1})
+	histogram.Histogram.Observe(0.5)
+	histograms := []*metrics.Metric{histogram}
+	hRows := mock.NewRows([]string{"name", "labels", "value"})
+	for _, h := range histograms {
+		data, errMarshal := json.Marshal(h.Histogram)
+		require.NoError(t, errMarshal)
+		hRows = hRows.AddRow(h.ID, metrics.EncodeLabels(h.Labels), string(data))
+	}
+	mock.ExpectQuery("SELECT name, labels, value FROM histogram").
+		WillReturnRows(hRows)
 	mock.ExpectClose()
 
 	var expected []*metrics.Metric
 	expected = append(expected, gauges...)
 	expected = append(expected, counters...)
+	expected = append(expected, histograms...)
 
 	actual, err := storage.List(context.Background())
 	require.NoError(t, err)
@@ -137,11 +154,14 @@ func TestList(t *testing.T) {
 	for i := range actual {
 		require.Equal(t, actual[i].ID, expected[i].ID)
 		require.Equal(t, actual[i].MType, expected[i].MType)
+		require.Equal(t, actual[i].Labels, expected[i].Labels)
 		switch actual[i].MType {
 		case metrics.CounterType:
 			require.Equal(t, *actual[i].Delta, *expected[i].Delta)
 		case metrics.GaugeType:
 			require.Equal(t, *actual[i].Value, *expected[i].Value)
+		case metrics.HistogramType:
+			require.Equal(t, actual[i].Histogram, expected[i].Histogram)
 		default:
 			require.False(t, true)
 		}
@@ -150,6 +170,93 @@ func TestList(t *testing.T) {
 	require.NoError(t, mock.ExpectationsWereMet())
 }
 
+func TestPutHistogram(t *testing.T) {
+	stored := metrics.NewHistogram("Latency", []float64{0.1, 1})
+	stored.Histogram.Observe(0.05)
+	storedData, err := json.Marshal(stored.Histogram)
+	require.NoError(t, err)
+
+	metric := metrics.NewHistogram("Latency", []float64{0.1, 1})
+	metric.Histogram.Observe(0.5)
+	data, err := json.Marshal(metric.Histogram)
+	require.NoError(t, err)
+
+	merged := stored.Histogram.Copy()
+	require.NoError(t, merged.Add(metric.Histogram))
+	mergedData, err := json.Marshal(merged)
+	require.NoError(t, err)
+
+	t.Run("Put new histogram", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO histogram ").
+			WithArgs(metric.ID, "", string(data)).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("INSERT INTO histogram_history").
+			WithArgs(metric.ID, "", string(data)).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+		mock.ExpectClose()
+
+		storage := &DBStorage{db: db}
+		require.NoError(t, storage.Put(context.Background(), metric))
+		require.NoError(t, db.Close())
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Merge histogram", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO histogram ").
+			WithArgs(metric.ID, "", string(data)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery("SELECT value FROM histogram").
+			WithArgs(metric.ID, "").
+			WillReturnRows(mock.NewRows([]string{"value"}).AddRow(string(storedData)))
+		mock.ExpectExec("UPDATE histogram").
+			WithArgs(metric.ID, "", string(mergedData)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("INSERT INTO histogram_history").
+			WithArgs(metric.ID, "", string(data)).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+		mock.ExpectClose()
+
+		storage := &DBStorage{db: db}
+		require.NoError(t, storage.Put(context.Background(), metric))
+		require.NoError(t, db.Close())
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Merge histogram with other bounds", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+
+		other := metrics.NewHistogram("Latency", []float64{1, 10})
+		otherData, err := json.Marshal(other.Histogram)
+		require.NoError(t, err)
+
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO histogram ").
+			WithArgs(other.ID, "", string(otherData)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery("SELECT value FROM histogram").
+			WithArgs(other.ID, "").
+			WillReturnRows(mock.NewRows([]string{"value"}).AddRow(string(storedData)))
+		mock.ExpectRollback()
+		mock.ExpectClose()
+
+		storage := &DBStorage{db: db}
+		require.ErrorIs(t, storage.Put(context.Background(), other), ErrBadArgument)
+		require.NoError(t, db.Close())
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
 func TestPut(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -169,6 +276,33 @@ func TestPut(t *testing.T) {
 			err:      nil,
 			sqlQuery: "INSERT INTO gauge",
 		},
+		{
+			name: "Put Gauge with labels",
+			m: metrics.Metric{
+				ID: "G", MType: metrics.GaugeType, Value: pointy.Float64(1.0),
+				Labels: map[string]string{"host": "localhost"},
+			},
+			err:      nil,
+			sqlQuery: "INSERT INTO gauge",
+		},
+		{
+			name: "Bad Argument labels",
+			m: metrics.Metric{
+				ID: "G", MType: metrics.GaugeType, Value: pointy.Float64(1.0),
+				Labels: map[string]string{"host name": "localhost"},
+			},
+			err: ErrBadArgument,
+		},
+		{
+			name: "Bad Argument id",
+			m:    metrics.Metric{ID: "G{", MType: metrics.GaugeType, Value: pointy.Float64(1.0)},
+			err:  ErrBadArgument,
+		},
+		{
+			name: "Bad Argument gauge",
+			m:    metrics.Metric{ID: "G", MType: metrics.GaugeType, Value: pointy.Float64(math.Inf(1))},
+			err:  ErrBadArgument,
+		},
 		{
 			name: "Unknown metric",
 			m:    metrics.Metric{MType: "Unknown"},
@@ -202,12 +336,12 @@ func TestPut(t *testing.T) {
 				case metrics.CounterType:
 					require.NotNil(t, tt.m.Delta)
 					mock.ExpectExec(tt.sqlQuery).
-						WithArgs(tt.m.ID, *tt.m.Delta).
+						WithArgs(tt.m.ID, *tt.m.Delta, metrics.EncodeLabels(tt.m.Labels)).
 						WillReturnResult(sqlmock.NewResult(1, 1))
 				case metrics.GaugeType:
 					require.NotNil(t, tt.m.Value)
 					mock.ExpectExec(tt.sqlQuery).
-						WithArgs(tt.m.ID, *tt.m.Value).
+						WithArgs(tt.m.ID, *tt.m.Value, metrics.EncodeLabels(tt.m.Labels)).
 						WillReturnResult(sqlmock.NewResult(1, 1))
 				default:
 					require.False(t, true)
@@ -222,3 +356,125 @@ func TestPut(t *testing.T) {
 		})
 	}
 }
+
+func TestRange(t *testing.T) {
+	from := time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(time.Hour)
+
+	tests := []struct {
+		err      error
+		target   interface{}
+		m        metrics.Metric
+		name     string
+		sqlQuery string
+	}{
+		{
+			name:     "Range Counter",
+			m:        metrics.Metric{ID: "PollCount", MType: metrics.CounterType},
+			sqlQuery: "SELECT created_at, delta FROM counter_history",
+			target:   int64(1),
+		},
+		{
+			name:     "Range Gauge",
+			m:        metrics.Metric{ID: "RandomValue", MType: metrics.GaugeType},
+			sqlQuery: "SELECT created_at, value FROM gauge_history",
+			target:   1.0,
+		},
+		{
+			name: "Unknown metric",
+			m:    metrics.Metric{MType: "Unknown"},
+			err:  ErrUnknownMetricType,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+
+			storage := &DBStorage{db: db}
+
+			timestamp := from.Add(time.Minute)
+			if tt.err == nil {
+				mock.ExpectQuery(tt.sqlQuery).
+					WithArgs(tt.m.ID, "", from, to).
+					WillReturnRows(mock.NewRows([]string{"created_at", "value"}).AddRow(timestamp, tt.target))
+			}
+			mock.ExpectClose()
+
+			points, err := storage.Range(context.Background(), &tt.m, from, to)
+			if tt.err == nil {
+				require.NoError(t, err)
+				require.Len(t, points, 1)
+				require.Equal(t, timestamp, points[0].Timestamp)
+
+				switch tt.m.MType {
+				case metrics.CounterType:
+					require.NotNil(t, points[0].Delta)
+					require.Equal(t, *points[0].Delta, tt.target)
+				case metrics.GaugeType:
+					require.NotNil(t, points[0].Value)
+					require.Equal(t, *points[0].Value, tt.target)
+				default:
+					require.False(t, true)
+				}
+			} else {
+				require.ErrorIs(t, err, tt.err)
+			}
+			require.NoError(t, db.Close())
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestPutBatch(t *testing.T) {
+	counter := metrics.NewCounter("C", 1)
+	gauge := metrics.NewGauge("G", 1.0)
+	invalid := &metrics.Metric{ID: "G", MType: metrics.GaugeType}
+
+	t.Run("Commit valid metrics", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO counter").
+			WithArgs(counter.ID, *counter.Delta, "").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("INSERT INTO gauge").
+			WithArgs(gauge.ID, *gauge.Value, "").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+		mock.ExpectClose()
+
+		storage := &DBStorage{db: db}
+		errs, err := storage.PutBatch(context.Background(), []*metrics.Metric{counter, invalid, gauge})
+		require.NoError(t, err)
+		require.Len(t, errs, 3)
+		require.NoError(t, errs[0])
+		require.ErrorIs(t, errs[1], ErrBadArgument)
+		require.NoError(t, errs[2])
+		require.NoError(t, db.Close())
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Rollback on failure", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO counter").
+			WithArgs(counter.ID, *counter.Delta, "").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("INSERT INTO gauge").
+			WithArgs(gauge.ID, *gauge.Value, "").
+			WillReturnError(errors.New("connection reset"))
+		mock.ExpectRollback()
+		mock.ExpectClose()
+
+		storage := &DBStorage{db: db}
+		_, err = storage.PutBatch(context.Background(), []*metrics.Metric{counter, gauge})
+		require.Error(t, err)
+		require.NoError(t, db.Close())
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -3,6 +3,7 @@ package storage
 import (
 	"context"
 	"encoding/json"
+	"math"
 	"os"
 	"sync"
 	"time"
@@ -14,16 +15,24 @@ import (
 	"github.com/hikjik/go-metrics/internal/metrics"
 )
 
+// FileStorage хранит метрики в памяти, периодически сохраняя их в файл.
+// Ключами хранилища являются ключи временных рядов metrics.Metric.SeriesKey.
 type FileStorage struct {
-	Floats   map[string]float64
-	Integers map[string]int64
+	Floats      map[string]float64
+	Integers    map[string]int64
+	Histograms  map[string]*metrics.Histogram
+	History     map[string][]Point
+	historySize int
 	sync.RWMutex
 }
 
 func newFileStorage(ctx context.Context, cfg config.StorageConfig) (Storage, error) {
 	storage := &FileStorage{
-		Floats:   make(map[string]float64),
-		Integers: make(map[string]int64),
+		Floats:      make(map[string]float64),
+		Integers:    make(map[string]int64),
+		Histograms:  make(map[string]*metrics.Histogram),
+		History:     make(map[string][]Point),
+		historySize: cfg.HistorySize,
 	}
 
 	if cfg.Restore {
@@ -34,9 +43,13 @@ func newFileStorage(ctx context.Context, cfg config.StorageConfig) (Storage, err
 
 	go func() {
 		storeTicker := time.NewTicker(cfg.StoreInterval)
+		defer storeTicker.Stop()
 		for {
 			select {
 			case <-ctx.Done():
+				if err := storage.dump(cfg.StoreFile); err != nil {
+					log.Warn().Err(err).Msg("Failed to dump metrics storage")
+				}
 				return
 			case <-storeTicker.C:
 				if err := storage.dump(cfg.StoreFile); err != nil {
@@ -55,40 +68,97 @@ func (s *FileStorage) Put(_ context.Context, metric *metrics.Metric) error {
 	s.Lock()
 	defer s.Unlock()
 
+	return s.put(metric)
+}
+
+// PutBatch сохраняет значения набора метрик под одной блокировкой хранилища
+func (s *FileStorage) PutBatch(_ context.Context, collection []*metrics.Metric) ([]error, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	errs := make([]error, len(collection))
+	for i, metric := range collection {
+		errs[i] = s.put(metric)
+	}
+	return errs, nil
+}
+
+func (s *FileStorage) put(metric *metrics.Metric) error {
+	if !metrics.ValidateID(metric.ID) || !metrics.ValidateLabels(metric.Labels) {
+		return ErrBadArgument
+	}
+
 	switch metric.MType {
 	case metrics.GaugeType:
-		if metric.Value == nil {
+		if metric.Value == nil || math.IsNaN(*metric.Value) || math.IsInf(*metric.Value, 0) {
 			return ErrBadArgument
 		}
-		s.Floats[metric.ID] = *metric.Value
+		s.Floats[metric.SeriesKey()] = *metric.Value
+		s.record(metric, Point{Value: pointy.Float64(*metric.Value)})
 	case metrics.CounterType:
 		if metric.Delta == nil {
 			return ErrBadArgument
 		}
-		s.Integers[metric.ID] += *metric.Delta
+		s.Integers[metric.SeriesKey()] += *metric.Delta
+		s.record(metric, Point{Delta: pointy.Int64(*metric.Delta)})
+	case metrics.HistogramType:
+		if !metric.Histogram.Valid() {
+			return ErrBadArgument
+		}
+		key := metric.SeriesKey()
+		if stored, ok := s.Histograms[key]; ok {
+			if err := stored.Add(metric.Histogram); err != nil {
+				return ErrBadArgument
+			}
+		} else {
+			s.Histograms[key] = metric.Histogram.Copy()
+		}
+		s.record(metric, Point{Histogram: metric.Histogram.Copy()})
 	default:
 		return ErrUnknownMetricType
 	}
 	return nil
 }
 
+// record добавляет значение в историю метрики, удаляя самые старые значения
+// при превышении размера historySize
+func (s *FileStorage) record(metric *metrics.Metric, point Point) {
+	if s.historySize <= 0 {
+		return
+	}
+
+	key := historyKey(metric)
+	point.Timestamp = time.Now()
+	points := append(s.History[key], point)
+	if len(points) > s.historySize {
+		points = points[len(points)-s.historySize:]
+	}
+	s.History[key] = points
+}
+
 func (s *FileStorage) Get(_ context.Context, metric *metrics.Metric) error {
 	s.RLock()
 	defer s.RUnlock()
 
 	switch metric.MType {
 	case metrics.GaugeType:
-		value, ok := s.Floats[metric.ID]
+		value, ok := s.Floats[metric.SeriesKey()]
 		if !ok {
 			return ErrNotFound
 		}
 		metric.Value = pointy.Float64(value)
 	case metrics.CounterType:
-		delta, ok := s.Integers[metric.ID]
+		delta, ok := s.Integers[metric.SeriesKey()]
 		if !ok {
 			return ErrNotFound
 		}
 		metric.Delta = pointy.Int64(delta)
+	case metrics.HistogramType:
+		histogram, ok := s.Histograms[metric.SeriesKey()]
+		if !ok {
+			return ErrNotFound
+		}
+		metric.Histogram = histogram.Copy()
 	default:
 		return ErrUnknownMetricType
 	}
@@ -100,25 +170,74 @@ func (s *FileStorage) List(_ context.Context) ([]*metrics.Metric, error) {
 	defer s.RUnlock()
 
 	result := make([]*metrics.Metric, 0)
-	for id, value := range s.Floats {
-		result = append(result, metrics.NewGauge(id, value))
+	for key, value := range s.Floats {
+		id, labels, err := metrics.ParseSeriesKey(key)
+		if err != nil {
+			return nil, err
+		}
+		metric := metrics.NewGauge(id, value)
+		metric.Labels = labels
+		result = append(result, metric)
 	}
-	for id, delta := range s.Integers {
-		result = append(result, metrics.NewCounter(id, delta))
+	for key, delta := range s.Integers {
+		id, labels, err := metrics.ParseSeriesKey(key)
+		if err != nil {
+			return nil, err
+		}
+		metric := metrics.NewCounter(id, delta)
+		metric.Labels = labels
+		result = append(result, metric)
+	}
+	for key, histogram := range s.Histograms {
+		id, labels, err := metrics.ParseSeriesKey(key)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, &metrics.Metric{
+			ID:        id,
+			MType:     metrics.HistogramType,
+			Histogram: histogram.Copy(),
+			Labels:    labels,
+		})
 	}
 	return result, nil
 }
 
-func (s *FileStorage) dump(storeFile string) error {
+func (s *FileStorage) Range(_ context.Context, metric *metrics.Metric, from, to time.Time) ([]Point, error) {
 	s.RLock()
 	defer s.RUnlock()
 
-	file, err := os.OpenFile(storeFile, os.O_WRONLY|os.O_CREATE, 0777)
+	switch metric.MType {
+	case metrics.GaugeType, metrics.CounterType, metrics.HistogramType:
+	default:
+		return nil, ErrUnknownMetricType
+	}
+
+	result := make([]Point, 0)
+	for _, point := range s.History[historyKey(metric)] {
+		if point.Timestamp.Before(from) || point.Timestamp.After(to) {
+			continue
+		}
+		result = append(result, point)
+	}
+	return result, nil
+}
+
+// dump сохраняет метрики во временный файл и заменяет им файл storeFile,
+// чтобы при ошибке кодирования или записи не потерять сохраненные ранее метрики
+func (s *FileStorage) dump(storeFile string) error {
+	s.RLock()
+	data, err := json.Marshal(s)
+	s.RUnlock()
 	if err != nil {
 		return err
 	}
 
-	return json.NewEncoder(file).Encode(s)
+	tmp := storeFile + ".tmp"
+	if err = os.WriteFile(tmp, data, 0777); err != nil {
+		return err
+	}
+	return os.Rename(tmp, storeFile)
 }
 
 func (s *FileStorage) load(storeFile string) error {
@@ -129,6 +248,24 @@ func (s *FileStorage) load(storeFile string) error {
 	if err != nil {
 		return err
 	}
+	defer func() {
+		if err = file.Close(); err != nil {
+			log.Warn().Err(err).Msg("Failed to close storage file")
+		}
+	}()
+
+	if err = json.NewDecoder(file).Decode(&s); err != nil {
+		return err
+	}
+	if s.Histograms == nil {
+		s.Histograms = make(map[string]*metrics.Histogram)
+	}
+	if s.History == nil {
+		s.History = make(map[string][]Point)
+	}
+	return nil
+}
 
-	return json.NewDecoder(file).Decode(&s)
+func historyKey(metric *metrics.Metric) string {
+	return metric.MType + ":" + metric.SeriesKey()
 }
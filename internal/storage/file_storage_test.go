@@ -0,0 +1,111 @@
+package storage
+
+import (
+	"context"
+	"math"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hikjik/go-metrics/internal/config"
+	"github.com/hikjik/go-metrics/internal/metrics"
+)
+
+func newTestFileStorage(t *testing.T, historySize int) Storage {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	storage, err := newFileStorage(ctx, config.StorageConfig{
+		StoreFile:     t.TempDir() + "/storage.json",
+		StoreInterval: time.Hour,
+		HistorySize:   historySize,
+	})
+	require.NoError(t, err)
+	return storage
+}
+
+func TestFileStorageRange(t *testing.T) {
+	ctx := context.Background()
+	storage := newTestFileStorage(t, 2)
+
+	from := time.Now()
+	for i := 1; i <= 3; i++ {
+		require.NoError(t, storage.Put(ctx, metrics.NewCounter("PollCount", int64(i))))
+		require.NoError(t, storage.Put(ctx, metrics.NewGauge("Alloc", float64(i))))
+	}
+	to := time.Now()
+
+	points, err := storage.Range(ctx, &metrics.Metric{ID: "PollCount", MType: metrics.CounterType}, from, to)
+	require.NoError(t, err)
+	require.Len(t, points, 2)
+	require.Equal(t, int64(2), *points[0].Delta)
+	require.Equal(t, int64(3), *points[1].Delta)
+
+	points, err = storage.Range(ctx, &metrics.Metric{ID: "Alloc", MType: metrics.GaugeType}, from, to)
+	require.NoError(t, err)
+	require.Len(t, points, 2)
+	require.Equal(t, 2.0, *points[0].Value)
+	require.Equal(t, 3.0, *points[1].Value)
+
+	points, err = storage.Range(ctx, &metrics.Metric{ID: "Alloc", MType: metrics.GaugeType}, to.Add(time.Second), to.Add(time.Hour))
+	require.NoError(t, err)
+	require.Empty(t, points)
+
+	_, err = storage.Range(ctx, &metrics.Metric{ID: "Alloc", MType: "unknown"}, from, to)
+	require.ErrorIs(t, err, ErrUnknownMetricType)
+}
+
+func TestFileStorageHistoryDisabled(t *testing.T) {
+	ctx := context.Background()
+	storage := newTestFileStorage(t, 0)
+
+	require.NoError(t, storage.Put(ctx, metrics.NewGauge("Alloc", 1.0)))
+
+	points, err := storage.Range(ctx, &metrics.Metric{ID: "Alloc", MType: metrics.GaugeType}, time.Time{}, time.Now())
+	require.NoError(t, err)
+	require.Empty(t, points)
+}
+
+func TestFileStorageInvalidID(t *testing.T) {
+	ctx := context.Background()
+	storage := newTestFileStorage(t, 0)
+
+	require.ErrorIs(t, storage.Put(ctx, metrics.NewGauge(`Alloc{host="a"}`, 1.0)), ErrBadArgument)
+	require.NoError(t, storage.Put(ctx, metrics.NewGauge("Alloc", 1.0)))
+
+	collection, err := storage.List(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []*metrics.Metric{metrics.NewGauge("Alloc", 1.0)}, collection)
+}
+
+func TestFileStorageNonFinite(t *testing.T) {
+	ctx := context.Background()
+	storage := newTestFileStorage(t, 0)
+
+	require.ErrorIs(t, storage.Put(ctx, metrics.NewGauge("Alloc", math.NaN())), ErrBadArgument)
+	require.ErrorIs(t, storage.Put(ctx, metrics.NewGauge("Alloc", math.Inf(1))), ErrBadArgument)
+
+	collection, err := storage.List(ctx)
+	require.NoError(t, err)
+	require.Empty(t, collection)
+}
+
+func TestFileStorageDumpFailure(t *testing.T) {
+	storeFile := t.TempDir() + "/storage.json"
+	storage := &FileStorage{
+		Floats:   map[string]float64{"Alloc": 1.5},
+		Integers: map[string]int64{},
+	}
+	require.NoError(t, storage.dump(storeFile))
+	saved, err := os.ReadFile(storeFile)
+	require.NoError(t, err)
+
+	// при ошибке кодирования сохраненные ранее метрики не теряются
+	storage.Floats["Alloc"] = math.Inf(1)
+	require.Error(t, storage.dump(storeFile))
+	data, err := os.ReadFile(storeFile)
+	require.NoError(t, err)
+	require.Equal(t, saved, data)
+}
@@ -4,6 +4,7 @@ package storage
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/hikjik/go-metrics/internal/config"
 	"github.com/hikjik/go-metrics/internal/metrics"
@@ -16,16 +17,41 @@ var (
 	ErrBadArgument       = errors.New("bad argument")
 )
 
+// Point содержит значение метрики, записанное в момент времени Timestamp.
+// Для метрик типа CounterType и HistogramType хранится переданное приращение,
+// а не накопленное значение.
+type Point struct {
+	Timestamp time.Time          `json:"timestamp"`
+	Delta     *int64             `json:"delta,omitempty"`
+	Value     *float64           `json:"value,omitempty"`
+	Histogram *metrics.Histogram `json:"histogram,omitempty"`
+}
+
 // Storage определяет интерфейс для хранения метрик
 type Storage interface {
 	// Put сохраняет значение метрики
 	Put(ctx context.Context, metric *metrics.Metric) error
 
+	// PutBatch сохраняет значения набора метрик. Для каждой метрики возвращается
+	// ошибка ErrBadArgument или ErrUnknownMetricType, если ее значение некорректно,
+	// или nil. Корректные метрики сохраняются атомарно: при сбое хранилища
+	// возвращается ошибка и не сохраняется ни одно значение.
+	PutBatch(ctx context.Context, collection []*metrics.Metric) ([]error, error)
+
 	// Get возвращает значение метрики
 	Get(ctx context.Context, metric *metrics.Metric) error
 
 	// List возвращает список всех сохраненных метрик
 	List(ctx context.Context) ([]*metrics.Metric, error)
+
+	// Range возвращает упорядоченные по времени значения метрики,
+	// записанные в интервале [from, to]
+	Range(ctx context.Context, metric *metrics.Metric, from, to time.Time) ([]Point, error)
+}
+
+// IsRejected возвращает true, если ошибка сохранения вызвана некорректным значением метрики
+func IsRejected(err error) bool {
+	return errors.Is(err, ErrBadArgument) || errors.Is(err, ErrUnknownMetricType)
 }
 
 // New возвращает объект типа Storage
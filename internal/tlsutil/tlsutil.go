@@ -0,0 +1,86 @@
+// Package tlsutil предназначен для настройки TLS-соединений между агентом и сервером
+package tlsutil
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"github.com/hikjik/go-metrics/internal/config"
+)
+
+// ServerConfig возвращает настройки TLS сервера. Если сертификат сервера не задан,
+// возвращается nil. Если задан сертификат центра cfg.CAFile, сервер требует
+// от агентов сертификат, подписанный этим центром.
+func ServerConfig(cfg config.TLSConfig) (*tls.Config, error) {
+	if cfg.CertFile == "" && cfg.KeyFile == "" {
+		if cfg.CAFile != "" {
+			return nil, fmt.Errorf("client CA requires server certificate and key")
+		}
+		return nil, nil
+	}
+
+	certificate, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load server certificate: %w", err)
+	}
+
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{certificate},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if cfg.CAFile != "" {
+		if tlsConfig.ClientCAs, err = loadCertPool(cfg.CAFile); err != nil {
+			return nil, err
+		}
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return tlsConfig, nil
+}
+
+// ClientConfig возвращает настройки TLS агента. Если TLS не включен
+// и не задан ни один из сертификатов, возвращается nil.
+func ClientConfig(cfg config.TLSConfig) (*tls.Config, error) {
+	if !Enabled(cfg) {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{
+		ServerName: cfg.ServerName,
+		MinVersion: tls.VersionTLS12,
+	}
+
+	var err error
+	if cfg.CAFile != "" {
+		if tlsConfig.RootCAs, err = loadCertPool(cfg.CAFile); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.CertFile != "" || cfg.KeyFile != "" {
+		var certificate tls.Certificate
+		if certificate, err = tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile); err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+	return tlsConfig, nil
+}
+
+// Enabled возвращает true, если агент должен использовать TLS
+func Enabled(cfg config.TLSConfig) bool {
+	return cfg.Enabled || cfg.CAFile != "" || cfg.CertFile != "" || cfg.KeyFile != ""
+}
+
+func loadCertPool(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA certificate: %w", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("failed to parse CA certificate %s", path)
+	}
+	return pool, nil
+}
@@ -0,0 +1,178 @@
+package tlsutil
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hikjik/go-metrics/internal/config"
+)
+
+type testCertificate struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+// newCertificate создает сертификат, подписанный parent, или самоподписанный сертификат центра
+func newCertificate(t *testing.T, name string, parent *testCertificate) *testCertificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		DNSNames:     []string{name},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+
+	signer, signerKey := template, key
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage |= x509.KeyUsageCertSign
+	} else {
+		signer, signerKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCertificate{cert: cert, key: key}
+}
+
+// write сохраняет сертификат и ключ в формате PEM и возвращает пути к файлам
+func (c *testCertificate) write(t *testing.T, dir, name string) (string, string) {
+	certPath := filepath.Join(dir, name+".crt")
+	keyPath := filepath.Join(dir, name+".key")
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.cert.Raw})
+	require.NoError(t, os.WriteFile(certPath, certPEM, 0600))
+
+	keyDER, err := x509.MarshalECPrivateKey(c.key)
+	require.NoError(t, err)
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	require.NoError(t, os.WriteFile(keyPath, keyPEM, 0600))
+	return certPath, keyPath
+}
+
+// handshake устанавливает TLS-соединение между клиентом и сервером.
+// В TLS 1.3 клиент узнает об отказе сервера только при чтении данных,
+// поэтому после рукопожатия сервер отправляет клиенту один байт.
+func handshake(t *testing.T, serverConfig, clientConfig *tls.Config) error {
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", serverConfig)
+	require.NoError(t, err)
+	defer listener.Close()
+
+	go func() {
+		conn, errAccept := listener.Accept()
+		if errAccept != nil {
+			return
+		}
+		defer conn.Close()
+		_, _ = conn.Write([]byte{1})
+	}()
+
+	conn, err := tls.Dial("tcp", listener.Addr().String(), clientConfig)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = conn.Read(make([]byte, 1))
+	return err
+}
+
+func TestMutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca := newCertificate(t, "ca", nil)
+	caPath, _ := ca.write(t, dir, "ca")
+	serverCert, serverKey := newCertificate(t, "metrics.local", ca).write(t, dir, "server")
+	clientCert, clientKey := newCertificate(t, "agent", ca).write(t, dir, "client")
+	otherCert, otherKey := newCertificate(t, "agent", newCertificate(t, "other", nil)).write(t, dir, "other")
+
+	serverConfig, err := ServerConfig(config.TLSConfig{
+		CertFile: serverCert,
+		KeyFile:  serverKey,
+		CAFile:   caPath,
+	})
+	require.NoError(t, err)
+	require.Equal(t, tls.RequireAndVerifyClientCert, serverConfig.ClientAuth)
+
+	tests := []struct {
+		name    string
+		cfg     config.TLSConfig
+		wantErr bool
+	}{
+		{
+			name: "Client certificate signed by CA",
+			cfg: config.TLSConfig{
+				CAFile: caPath, CertFile: clientCert, KeyFile: clientKey, ServerName: "metrics.local",
+			},
+		},
+		{
+			name:    "No client certificate",
+			cfg:     config.TLSConfig{CAFile: caPath, ServerName: "metrics.local"},
+			wantErr: true,
+		},
+		{
+			name: "Client certificate signed by unknown CA",
+			cfg: config.TLSConfig{
+				CAFile: caPath, CertFile: otherCert, KeyFile: otherKey, ServerName: "metrics.local",
+			},
+			wantErr: true,
+		},
+		{
+			name: "Unexpected server name",
+			cfg: config.TLSConfig{
+				CAFile: caPath, CertFile: clientCert, KeyFile: clientKey, ServerName: "example.com",
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientConfig, err := ClientConfig(tt.cfg)
+			require.NoError(t, err)
+
+			err = handshake(t, serverConfig, clientConfig)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestDisabledTLS(t *testing.T) {
+	serverConfig, err := ServerConfig(config.TLSConfig{})
+	require.NoError(t, err)
+	assert.Nil(t, serverConfig)
+
+	clientConfig, err := ClientConfig(config.TLSConfig{})
+	require.NoError(t, err)
+	assert.Nil(t, clientConfig)
+
+	clientConfig, err = ClientConfig(config.TLSConfig{Enabled: true})
+	require.NoError(t, err)
+	assert.NotNil(t, clientConfig)
+
+	_, err = ServerConfig(config.TLSConfig{CAFile: "ca.crt"})
+	assert.Error(t, err)
+}